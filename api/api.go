@@ -0,0 +1,113 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/D0hwQ1/Blockchain-With-Go/chain"
+	"github.com/D0hwQ1/Blockchain-With-Go/mempool"
+	"github.com/gorilla/mux"
+)
+
+// web, pow 모드가 함께 쓰는 HTTP 라우트: 체인 조회, mempool, 머클 증명, 감사
+// 블록 생성 (POST /) 과 모드별 라우트는 각 모드의 makeMuxRouter 에서 등록
+func Routes(muxRouter *mux.Router, c *chain.Chain, txPool *mempool.Mempool) {
+	muxRouter.HandleFunc("/", handleGetBlockchain(c)).Methods("GET")
+	muxRouter.HandleFunc("/tx", handleGetMempool(txPool)).Methods("GET")
+	muxRouter.HandleFunc("/tx", handleWriteTransaction(txPool)).Methods("POST")
+	muxRouter.HandleFunc("/blocks/{hash}/proof/{entry}", handleGetProof(c)).Methods("GET")
+	muxRouter.HandleFunc("/audit", handleAudit(c)).Methods("GET")
+}
+
+// GET 메소드로 조회되었을 때, 웹뷰에 json으로 가공된 블록 정보 표시
+func handleGetBlockchain(c *chain.Chain) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bytes, err := json.MarshalIndent(c.Blocks(), "" /* prefix */, "  " /* indent */)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		io.WriteString(w, string(bytes))
+	}
+}
+
+// 블록에 트랜잭션이 포함되어 있음을 증명하는 머클 경로 - entry 는 트랜잭션 위치 또는 해쉬
+func handleGetProof(c *chain.Chain) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		block, ok := c.Block(vars["hash"])
+		if !ok {
+			RespondWithJSON(w, r, http.StatusNotFound, "block not found")
+			return
+		}
+		proof, err := block.EntryProof(vars["entry"])
+		if err != nil {
+			RespondWithJSON(w, r, http.StatusNotFound, err.Error())
+			return
+		}
+
+		RespondWithJSON(w, r, http.StatusOK, proof)
+	}
+}
+
+// 전체 체인을 검증하여 처음으로 깨진 블록의 위치와 실패한 규칙을 표시
+func handleAudit(c *chain.Chain) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		RespondWithJSON(w, r, http.StatusOK, c.Audit())
+	}
+}
+
+// 서명된 트랜잭션을 mempool 에 추가 - 다음에 생성되는 블록에 담김
+func handleWriteTransaction(txPool *mempool.Mempool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var tx chain.Transaction
+
+		if err := json.NewDecoder(r.Body).Decode(&tx); err != nil {
+			RespondWithJSON(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		defer r.Body.Close()
+
+		if err := txPool.Add(tx); err != nil {
+			RespondWithJSON(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		RespondWithJSON(w, r, http.StatusAccepted, tx.Hash())
+	}
+}
+
+// 블록에 담기기를 기다리는 트랜잭션 목록
+func handleGetMempool(txPool *mempool.Mempool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		RespondWithJSON(w, r, http.StatusOK, txPool.Pending())
+	}
+}
+
+// 생성한 블록의 정보를 json으로 클라이언트에 response
+func RespondWithJSON(w http.ResponseWriter, r *http.Request, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	response, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("HTTP 500: Internal Server Error"))
+		return
+	}
+
+	w.WriteHeader(code)
+	w.Write(response)
+}
+
+// tcp, pos 대화형 모드의 "tx {서명된 트랜잭션 JSON}" 입력을 mempool 에 추가하고 결과 메시지 반환
+func AddTransaction(txPool *mempool.Mempool, data string) string {
+	var tx chain.Transaction
+	if err := json.Unmarshal([]byte(data), &tx); err != nil {
+		return "invalid transaction: " + err.Error()
+	}
+	if err := txPool.Add(tx); err != nil {
+		return "rejected transaction: " + err.Error()
+	}
+	return "pending transaction: " + tx.Hash()
+}
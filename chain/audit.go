@@ -0,0 +1,133 @@
+package chain
+
+import (
+	"errors"
+	"strconv"
+)
+
+// 전체 체인 검증 결과 - 유효하지 않으면 처음 깨진 높이와 실패한 규칙, 기대값/실제값을 담음
+type AuditReport struct {
+	Valid    bool
+	Length   int    // 검증한 블록 수
+	Height   int    // 처음으로 유효하지 않은 블록 높이 (유효하면 -1)
+	Rule     string // 실패한 검증 규칙
+	Error    string
+	Expected string // 규칙이 기대한 값 (해쉬 등)
+	Actual   string // 블록에 들어 있는 값
+}
+
+func (c *Chain) Audit() AuditReport { // 현재 체인 전체 검증
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.audit(c.blocks)
+}
+
+// 저장소 등에서 읽어 온 블록들을 체인 규칙으로 검증 (체인을 바꾸지 않음)
+func AuditBlocks(genesis *GenesisConfig, blocks []Block, rules ...Rule) AuditReport {
+	c := &Chain{rules: rules, genesis: genesis}
+	return c.audit(blocks)
+}
+
+func (c *Chain) audit(blocks []Block) AuditReport {
+	report := AuditReport{Length: len(blocks), Height: -1}
+
+	if len(blocks) == 0 {
+		report.Height, report.Rule, report.Error = 0, "genesis", ErrEmptyChain.Error()
+		return report
+	}
+	if genesisBlock := c.genesis.Block(); blocks[0].Hash != genesisBlock.Hash {
+		report.Height, report.Rule, report.Error = 0, "genesis", ErrGenesis.Error()
+		report.Expected, report.Actual = genesisBlock.Hash, blocks[0].Hash
+		return report
+	}
+
+	i, err := c.check(blocks, false)
+	if err == nil {
+		report.Valid = true
+		return report
+	}
+
+	block := blocks[i]
+	report.Height, report.Error = i, err.Error()
+	if i == 0 { // genesis 블록의 해쉬는 맞지만 내용이나 누적 작업량이 설정과 다름
+		report.Rule = "genesis"
+		report.Expected, report.Actual = c.genesis.Block().TotalWork, block.TotalWork
+		if errors.Is(err, ErrHash) {
+			report.Expected, report.Actual = CalculateHash(block), block.Hash
+		}
+		return report
+	}
+	switch {
+	case errors.Is(err, ErrVersion):
+		report.Rule = "version"
+		report.Expected, report.Actual = strconv.Itoa(HeaderVersion), strconv.Itoa(block.Version)
+	case errors.Is(err, ErrChainID):
+		report.Rule = "chain-id"
+		report.Expected, report.Actual = blocks[i-1].ChainID, block.ChainID
+	case errors.Is(err, ErrIndex):
+		report.Rule = "index"
+		report.Expected, report.Actual = strconv.Itoa(blocks[i-1].Index+1), strconv.Itoa(block.Index)
+	case errors.Is(err, ErrPrevHash):
+		report.Rule = "prev-hash"
+		report.Expected, report.Actual = blocks[i-1].Hash, block.PrevHash
+	case errors.Is(err, ErrHash):
+		report.Rule = "hash"
+		report.Expected, report.Actual = CalculateHash(block), block.Hash
+	case errors.Is(err, ErrWork):
+		report.Rule = "total-work"
+		report.Expected, report.Actual = CumulativeWork(blocks[i-1], block), block.TotalWork
+	case errors.Is(err, ErrNoWork):
+		report.Rule = "work"
+		report.Expected, report.Actual = "0", block.Bits.String()
+	case errors.Is(err, ErrTimestamp):
+		report.Rule = "median-time"
+		report.Expected = "> " + strconv.FormatInt(MedianTime(blocks[:i], c.genesis.MedianTimeSpan), 10)
+		report.Actual = strconv.FormatInt(block.Timestamp, 10)
+	case errors.Is(err, ErrSigner):
+		report.Rule = "signature"
+		report.Expected, report.Actual = block.Validator, block.PubKey
+	case errors.Is(err, ErrUnsigned):
+		report.Rule = "signature"
+		report.Expected = "validator"
+	case errors.Is(err, ErrLeader):
+		report.Rule = "leader"
+		st := newState(c.genesis) // 부모 시점의 일정에서 블록의 slot 제안자
+		for _, b := range blocks[:i] {
+			st.apply(c.genesis, b)
+		}
+		report.Expected, report.Actual = st.leader(c.genesis, c.genesis.Slot(block.Timestamp), i), block.Validator
+	case errors.Is(err, ErrReveal):
+		report.Rule = "reveal"
+		report.Actual = block.Reveal
+	case errors.Is(err, ErrSlot):
+		report.Rule = "slot"
+		report.Expected = "> " + strconv.Itoa(c.genesis.Slot(blocks[i-1].Timestamp))
+		report.Actual = strconv.Itoa(c.genesis.Slot(block.Timestamp))
+	case errors.Is(err, ErrMerkleRoot):
+		report.Rule = "merkle-root"
+		report.Expected, report.Actual = MerkleRoot(block.Transactions), block.MerkleRoot
+	case errors.Is(err, ErrPayload), errors.Is(err, ErrStakeInPayload):
+		report.Rule = "payload"
+		report.Actual = block.Payload.Type
+	case errors.Is(err, ErrUncle):
+		report.Rule = "uncles"
+		report.Expected, report.Actual = UnclesHash(block.Uncles), block.UnclesHash
+	case errors.Is(err, ErrReward):
+		report.Rule = "reward"
+		report.Expected, report.Actual = strconv.Itoa(c.genesis.Reward(block.Index)), strconv.Itoa(block.Reward)
+	case errors.Is(err, ErrStake), errors.Is(err, ErrBalance), errors.Is(err, ErrBonded):
+		report.Rule = "stake"
+		report.Actual = block.Hash
+	case errors.Is(err, ErrEvidence), errors.Is(err, ErrSlashed):
+		report.Rule = "slash"
+		report.Actual = block.Hash
+	case errors.Is(err, ErrSignature), errors.Is(err, ErrDuplicateTx), errors.Is(err, ErrTooManyTxs):
+		report.Rule = "transactions"
+		report.Actual = block.Hash
+	default: // 모드별 추가 규칙
+		report.Rule = "consensus"
+		report.Actual = block.Hash
+	}
+	return report
+}
@@ -0,0 +1,89 @@
+package chain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+
+	"github.com/D0hwQ1/Blockchain-With-Go/wallet"
+)
+
+// PoS 제안자 일정의 무작위성 (commit-reveal beacon)
+// 검증자는 블록마다 다음에 공개할 비밀값의 해쉬를 약속 (Commit) 하고, 앞서 약속한 비밀값을 공개 (Reveal) 함
+// 공개된 비밀값을 차례로 섞은 beacon 으로 epoch seed 를 만들고 블록 해쉬는 쓰지 않음 - 제안자가 블록 내용을 바꿔 가며 (grinding)
+// 다음 일정을 고를 수 없고, 약속한 값을 공개하거나 블록을 내지 않는 것 (그 slot 의 보상을 포기) 만 고를 수 있음
+//
+// 비밀값은 검증자 지갑으로 RevealMessage 에 서명한 값 - ed25519 서명은 같은 메시지에 항상 같으므로 따로 보관하지 않아도 됨
+
+var ErrReveal = errors.New("block does not reveal the secret its validator committed to")
+
+func RevealMessage(chainID string, round int) string { // 검증자의 round 번째 비밀값을 만드는 메시지
+	return chainID + ":reveal:" + strconv.Itoa(round)
+}
+
+func CommitOf(secret string) string { // 비밀값의 약속 (sha256, hex)
+	h := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(h[:])
+}
+
+// 검증자가 round 번째로 약속하는 블록 (Chain.Round) 에 담을 공개값과 약속 - 첫 블록 (round 0) 은 약속만 함
+func RevealPair(w *wallet.Wallet, chainID string, round int) (reveal, commit string) {
+	if round > 0 {
+		reveal = w.Sign([]byte(RevealMessage(chainID, round-1)))
+	}
+	return reveal, CommitOf(w.Sign([]byte(RevealMessage(chainID, round))))
+}
+
+func (s *state) checkReveal(validator, reveal, commit string) error {
+	if b, err := hex.DecodeString(commit); err != nil || len(b) != sha256.Size {
+		return ErrReveal
+	}
+	expected, ok := s.commits[validator]
+	if !ok { // 처음 제안하는 블록은 약속만 함
+		if reveal != "" {
+			return ErrReveal
+		}
+		return nil
+	}
+	if CommitOf(reveal) != expected {
+		return ErrReveal
+	}
+	return nil
+}
+
+// genesis 블록의 해쉬로 beacon 을 시작하고, 이후 검증자 블록마다 약속을 기록하고 공개된 비밀값을 섞음
+// stake 가 없어 누구나 제안할 수 있는 동안의 공개값은 섞지 않음 (여러 주소로 번갈아 제안하며 고를 수 없도록)
+func (s *state) mixReveal(block Block) {
+	if block.Index == 0 {
+		s.beacon = mix(nil, block.Hash)
+		return
+	}
+	if block.Validator == "" {
+		return
+	}
+	if block.Reveal != "" && s.epochStakes[block.Validator] > 0 {
+		s.beacon = mix(s.beacon, block.Reveal)
+	}
+	s.commits[block.Validator] = block.Commit
+	s.rounds[block.Validator]++
+}
+
+func mix(beacon []byte, value string) []byte { // 새 슬라이스를 만듦 (이전 값은 복사본과 공유됨)
+	h := sha256.Sum256(append(append([]byte{}, beacon...), []byte(value)...))
+	return h[:]
+}
+
+func (c *Chain) Round(address string) int { // 검증자가 지금까지 약속한 비밀값 수 - 다음 블록에 담을 값은 RevealPair(w, ChainID, Round)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.state.rounds[address]
+}
+
+func (c *Chain) CheckReveal(validator, reveal, commit string) error { // 다음 블록에 담을 공개값과 약속이 맞는지
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.state.checkReveal(validator, reveal, commit)
+}
@@ -0,0 +1,125 @@
+package chain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/D0hwQ1/Blockchain-With-Go/wallet"
+)
+
+// 블록 검증 실패 사유
+var (
+	ErrIndex     = errors.New("block has inconsistent index")
+	ErrPrevHash  = errors.New("block has inconsistent hashes")
+	ErrHash      = errors.New("block has inconsistent hash generation")
+	ErrTimestamp = errors.New("block timestamp is not above the median of recent blocks")
+	ErrFuture    = errors.New("block timestamp is too far in the future")
+	ErrChainID   = errors.New("block belongs to a different chain")
+	ErrSigner    = errors.New("block is not signed by its validator")
+	ErrUnsigned  = errors.New("block has no validator")
+)
+
+type Header struct {
+	Version    int     // 헤더 인코딩 버전 (HeaderVersion)
+	ChainID    string  // 블록이 속한 체인 식별자 (genesis 설정)
+	Index      int     // 데이터 레코드 위치
+	Timestamp  int64   // 데이터 기록되는 시간 (Unix 나노초)
+	PrevHash   string  // 이전 블록의 sha256 해쉬값
+	Bits       Compact // PoW: 해쉬가 넘지 말아야 할 256비트 목표값 (compact 인코딩, 0 이면 PoW 블록이 아님)
+	Nonce      string  // PoW: 난이도를 만족시키기 위해 바꿔가며 대입하는 값
+	Validator  string  // PoS: 블록을 제안한 검증자 주소
+	Miner      string  // PoW: 채굴 보상을 받을 주소
+	Reward     int     // PoW: 채굴 보상 (coinbase), genesis 의 BlockReward 와 HalvingInterval 로 정해짐
+	MerkleRoot string  // Transactions 의 머클 루트
+	UnclesHash string  // Uncles 의 해쉬 (UnclesHash)
+	Commit     string  // PoS: 제안자가 다음 블록에서 공개할 비밀값의 sha256 (CommitOf)
+	Reveal     string  // PoS: 제안자가 이전 블록에서 약속한 비밀값 (첫 블록이면 비어 있음)
+}
+
+type Block struct {
+	Header
+	Payload      Payload       // 블록에 기록하는 데이터 (BPM, JSON, 바이트)
+	Transactions []Transaction // mempool 에서 가져온 서명된 트랜잭션
+	Uncles       []Uncle       // PoW: 같은 높이에서 경쟁에 진 최근 블록
+	Hash         string        // 해당 블록 sha256 해쉬값
+	TotalWork    string        // genesis 부터 이 블록까지의 누적 작업량 (hex), 분기 선택에 사용
+	PubKey       string        // PoS: Validator 의 공개키 (hex)
+	Signature    string        // PoS: Validator 가 Hash 에 서명한 값 (hex)
+}
+
+func CalculateHash(block Block) string { // 정규 인코딩으로 해쉬 생성
+	h := sha256.New()
+	h.Write(hashPreimage(block))
+	hashed := h.Sum(nil)
+	return hex.EncodeToString(hashed)
+}
+
+// 이전 블록을 이어 새 블록의 헤더를 채움 (해쉬는 호출한 쪽에서 필드를 마저 채운 뒤 계산)
+func NewBlock(oldBlock Block, payload Payload, txs ...Transaction) Block {
+	var newBlock Block
+
+	newBlock.Version = HeaderVersion
+	newBlock.ChainID = oldBlock.ChainID
+	newBlock.Index = oldBlock.Index + 1
+	newBlock.Timestamp = time.Now().UnixNano()
+	newBlock.Payload = payload
+	newBlock.Transactions = txs
+	newBlock.MerkleRoot = MerkleRoot(txs)
+	newBlock.PrevHash = oldBlock.Hash
+	newBlock.TotalWork = CumulativeWork(oldBlock, newBlock) // 목표값이나 uncle 을 바꾸면 다시 계산
+
+	return newBlock
+}
+
+func GenerateBlock(oldBlock Block, payload Payload, txs ...Transaction) Block { // 페이로드를 입력받아 블록 생성
+	newBlock := NewBlock(oldBlock, payload, txs...)
+	newBlock.Hash = CalculateHash(newBlock)
+	return newBlock
+}
+
+func (b *Block) Sign(w *wallet.Wallet) { // 해쉬를 계산한 뒤 블록 제안자가 서명
+	b.PubKey = w.PublicKey()
+	b.Signature = w.Sign([]byte(b.Hash))
+}
+
+func IsBlockValid(newBlock, oldBlock Block) error { // 추가할 블록 변조 체크
+	if newBlock.Version != HeaderVersion {
+		return ErrVersion
+	}
+	if oldBlock.ChainID != newBlock.ChainID {
+		return ErrChainID
+	}
+	if oldBlock.Index+1 != newBlock.Index {
+		return ErrIndex
+	}
+	if oldBlock.Hash != newBlock.PrevHash {
+		return ErrPrevHash
+	}
+	if MerkleRoot(newBlock.Transactions) != newBlock.MerkleRoot {
+		return ErrMerkleRoot
+	}
+	if UnclesHash(newBlock.Uncles) != newBlock.UnclesHash {
+		return ErrUncle
+	}
+	if err := newBlock.Payload.Validate(); err != nil {
+		return err
+	}
+	if newBlock.Payload.Type == TypeStake || newBlock.Payload.Type == TypeSlash { // stake, slash 는 보낸 사람이 서명한 트랜잭션으로만
+		return ErrStakeInPayload
+	}
+	if CalculateHash(newBlock) != newBlock.Hash {
+		return ErrHash
+	}
+	if CumulativeWork(oldBlock, newBlock) != newBlock.TotalWork {
+		return ErrWork
+	}
+	if newBlock.Validator != "" { // 검증자가 제안한 블록은 그 주소의 키로 서명되어야 함
+		if err := wallet.VerifyAddress(newBlock.Validator, newBlock.PubKey, []byte(newBlock.Hash), newBlock.Signature); err != nil {
+			return ErrSigner
+		}
+	}
+
+	return nil
+}
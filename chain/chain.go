@@ -0,0 +1,256 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+)
+
+var ErrEmptyChain = errors.New("blockchain has no genesis block")
+
+// 모드별 추가 검증 규칙 (예: PoW 난이도) - blocks는 newBlock의 부모까지의 체인
+// 규칙 없이 연 체인은 작업량을 검증할 수 없으므로 목표값 (Bits) 이나 uncle 이 있는 블록을 받지 않음
+type Rule func(blocks []Block, newBlock Block) error
+
+// 블록을 디스크에 보관하는 저장소 (storage.Store)
+type Store interface {
+	Load() ([]Block, error)
+	Append(block Block) error
+	Truncate(n int) error // 앞의 n 개 블록만 남김
+}
+
+type Chain struct {
+	mutex   sync.Mutex
+	blocks  []Block
+	rules   []Rule
+	store   Store
+	genesis *GenesisConfig
+	state   *state // 블록들을 적용한 트랜잭션 색인, 잔액, stake
+}
+
+func New(genesis *GenesisConfig, rules ...Rule) *Chain { // 메모리에만 유지되는 체인
+	c := &Chain{rules: rules, genesis: genesis}
+	c.setBlocks([]Block{genesis.Block()})
+	return c
+}
+
+// 저장소에서 체인을 불러옴 - 비어 있으면 genesis 블록으로 시작하고, 유효하지 않은 꼬리 블록은 잘라냄
+func Open(store Store, genesis *GenesisConfig, rules ...Rule) (*Chain, error) {
+	c := &Chain{rules: rules, store: store, genesis: genesis}
+	genesisBlock := genesis.Block()
+
+	blocks, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	if len(blocks) == 0 {
+		if err := store.Append(genesisBlock); err != nil {
+			return nil, err
+		}
+		c.setBlocks([]Block{genesisBlock})
+		return c, nil
+	}
+
+	if blocks[0].Hash != genesisBlock.Hash { // 다른 genesis 설정으로 만든 데이터 디렉토리
+		return nil, ErrGenesis
+	}
+	if i, err := c.check(blocks, false); i == 0 { // genesis 블록까지 자르면 체인이 비므로 열지 않음
+		return nil, fmt.Errorf("%w: %v", ErrGenesis, err)
+	} else if err != nil {
+		log.Printf("chain: dropping stored blocks from index %d: %v", i, err)
+		if err := store.Truncate(i); err != nil {
+			return nil, err
+		}
+		blocks = blocks[:i]
+	}
+	c.setBlocks(blocks)
+	return c, nil
+}
+
+func (c *Chain) setBlocks(blocks []Block) { // 블록과 상태를 함께 교체 (blocks 는 검증된 체인)
+	c.blocks = blocks
+	c.state = newState(c.genesis)
+	for _, block := range blocks {
+		c.state.apply(c.genesis, block)
+	}
+}
+
+func (c *Chain) Block(hash string) (Block, bool) { // 해쉬로 블록 조회
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for i := len(c.blocks) - 1; i >= 0; i-- {
+		if c.blocks[i].Hash == hash {
+			return c.blocks[i], true
+		}
+	}
+	return Block{}, false
+}
+
+func (c *Chain) HasTransaction(hash string) bool { // 이미 블록에 담긴 트랜잭션인지
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.state.included[hash]
+}
+
+func (c *Chain) Blocks() []Block { // 현재 체인의 복사본
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	blocks := make([]Block, len(c.blocks))
+	copy(blocks, c.blocks)
+	return blocks
+}
+
+func (c *Chain) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return len(c.blocks)
+}
+
+func (c *Chain) TotalWork() string { // 현재 체인의 누적 작업량 (hex)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.blocks[len(c.blocks)-1].TotalWork
+}
+
+func (c *Chain) Tip() Block { // 마지막 블록
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.blocks[len(c.blocks)-1]
+}
+
+// 새 블록을 검증한 뒤 체인 끝에 추가
+func (c *Chain) Append(newBlock Block) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if err := c.validate(c.blocks, newBlock, c.state, true); err != nil {
+		return err
+	}
+	if c.store != nil {
+		if err := c.store.Append(newBlock); err != nil {
+			return err
+		}
+	}
+	c.blocks = append(c.blocks, newBlock)
+	c.state.apply(c.genesis, newBlock)
+	return nil
+}
+
+// fork(분기) 되었을 때, 누적 작업량이 더 많은 체인을 선택 (길이만 긴 체인은 받지 않음)
+// 마지막 블록의 TotalWork 끼리 먼저 비교하고, 더 무거운 체인만 전체 검증 (TotalWork 도 블록마다 다시 계산해서 확인)
+func (c *Chain) Replace(newBlocks []Block) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if len(newBlocks) == 0 {
+		return false
+	}
+	if newBlocks[len(newBlocks)-1].totalWork().Cmp(c.blocks[len(c.blocks)-1].totalWork()) <= 0 { // 같으면 먼저 받은 체인 유지
+		return false
+	}
+	if newBlocks[0].Hash != c.blocks[0].Hash { // genesis 가 다른 체인은 받지 않음
+		log.Println(ErrGenesis)
+		return false
+	}
+	if _, err := c.check(newBlocks, true); err != nil {
+		return false
+	}
+	if c.store != nil {
+		if err := c.persist(newBlocks); err != nil {
+			log.Println(err)
+			return false
+		}
+	}
+	c.setBlocks(newBlocks)
+	return true
+}
+
+// 저장소에서 공통 조상 이후의 블록을 지우고 새 체인의 블록으로 다시 씀
+func (c *Chain) persist(newBlocks []Block) error {
+	common := 0
+	for common < len(c.blocks) && c.blocks[common].Hash == newBlocks[common].Hash {
+		common++
+	}
+	if err := c.store.Truncate(common); err != nil {
+		return err
+	}
+	for _, block := range newBlocks[common:] {
+		if err := c.store.Append(block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Chain) Validate() error { // 전체 체인 변조 체크
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	_, err := c.check(c.blocks, false)
+	return err
+}
+
+// blocks 는 newBlock 의 부모까지의 체인, st 는 blocks 를 적용한 상태 (바꾸지 않음)
+// clock 이 true 면 지금 시간과 비교하는 규칙 (ErrFuture, ErrSlot 의 미래 slot) 도 검사 - 새로 받는 블록에만 적용
+func (c *Chain) validate(blocks []Block, newBlock Block, st *state, clock bool) error {
+	if err := IsBlockValid(newBlock, blocks[len(blocks)-1]); err != nil {
+		return err
+	}
+	if len(c.rules) == 0 && (newBlock.Bits != 0 || len(newBlock.Uncles) > 0) { // 검증하지 않은 작업량으로 분기 선택을 뒤집지 못하도록
+		return ErrNoWork
+	}
+	if err := c.checkTime(blocks, newBlock, clock); err != nil {
+		return err
+	}
+	if err := checkTransactions(newBlock, st.included); err != nil {
+		return err
+	}
+	if err := st.clone().transition(c.genesis, newBlock); err != nil { // stake 트랜잭션을 잔액에 비추어 검증
+		return err
+	}
+	if err := c.checkLeader(newBlock, st, clock); err != nil {
+		return err
+	}
+	if err := c.checkReward(newBlock); err != nil {
+		return err
+	}
+	if err := c.checkUncles(blocks, newBlock); err != nil {
+		return err
+	}
+	for _, rule := range c.rules {
+		if err := rule(blocks, newBlock); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 전체 체인을 검증하고 처음으로 유효하지 않은 블록의 위치를 반환
+// 이미 받아들인 블록 (저장소에서 불러온 체인, 감사) 은 clock 을 false 로 - 시계가 늦은 노드가 저장된 블록을 지우지 않도록
+func (c *Chain) check(blocks []Block, clock bool) (int, error) {
+	if len(blocks) == 0 {
+		return 0, ErrEmptyChain
+	}
+	if CalculateHash(blocks[0]) != blocks[0].Hash {
+		return 0, ErrHash
+	}
+	if Work(blocks[0].Bits).Text(16) != blocks[0].TotalWork {
+		return 0, ErrWork
+	}
+	st := newState(c.genesis)
+	st.apply(c.genesis, blocks[0])
+	for i := 1; i < len(blocks); i++ {
+		if err := c.validate(blocks[:i], blocks[i], st, clock); err != nil {
+			return i, err
+		}
+		st.apply(c.genesis, blocks[i])
+	}
+	return len(blocks), nil
+}
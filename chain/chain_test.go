@@ -0,0 +1,140 @@
+package chain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/D0hwQ1/Blockchain-With-Go/wallet"
+)
+
+func TestReplace(t *testing.T) {
+	genesis := DefaultGenesis
+	anyWork := func(blocks []Block, newBlock Block) error { return nil } // 작업량은 검증하지 않고 분기 선택만 확인
+
+	build := func(parent Block, bits ...Compact) []Block {
+		var blocks []Block
+		for i, b := range bits {
+			block := NewBlock(parent, BPMPayload(60+i))
+			block.Bits = b
+			block.TotalWork = CumulativeWork(parent, block)
+			block.Hash = CalculateHash(block)
+			blocks = append(blocks, block)
+			parent = block
+		}
+		return blocks
+	}
+
+	tests := []struct {
+		name    string
+		current []Compact // genesis 뒤의 블록 목표값
+		fork    []Compact
+		replace bool
+	}{
+		{"longer but lighter", []Compact{0x1f00ffff}, []Compact{0, 0, 0}, false},
+		{"shorter but heavier", []Compact{0, 0, 0}, []Compact{0x1f00ffff}, true},
+		{"equal work", []Compact{0x1f00ffff}, []Compact{0x1f00ffff}, false},
+		{"heavier", []Compact{0x1f00ffff}, []Compact{0x1f00ffff, 0}, true},
+	}
+	for _, test := range tests {
+		c := New(&genesis, anyWork)
+		for _, block := range build(c.Tip(), test.current...) {
+			if err := c.Append(block); err != nil {
+				t.Fatalf("%s: %v", test.name, err)
+			}
+		}
+		tip := c.Tip()
+
+		fork := append([]Block{genesis.Block()}, build(genesis.Block(), test.fork...)...)
+		if got := c.Replace(fork); got != test.replace {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.replace)
+		}
+		want := tip.Hash
+		if test.replace {
+			want = fork[len(fork)-1].Hash
+		}
+		if c.Tip().Hash != want {
+			t.Errorf("%s: tip is %s, want %s", test.name, c.Tip().Hash, want)
+		}
+	}
+}
+
+func TestReplaceRejectsForgedWork(t *testing.T) {
+	genesis := DefaultGenesis
+	c := New(&genesis, func(blocks []Block, newBlock Block) error { return nil })
+
+	block := GenerateBlock(genesis.Block(), BPMPayload(1))
+	block.TotalWork = "ffffffff" // 목표값 없이 누적 작업량만 부풀림
+	block.Hash = CalculateHash(block)
+	if c.Replace([]Block{genesis.Block(), block}) {
+		t.Error("accepted a chain whose total work does not match its blocks")
+	}
+}
+
+// 메모리에만 블록을 두는 저장소
+type memStore struct {
+	blocks    []Block
+	truncated bool
+}
+
+func (s *memStore) Load() ([]Block, error) { return append([]Block{}, s.blocks...), nil }
+
+func (s *memStore) Append(block Block) error {
+	s.blocks = append(s.blocks, block)
+	return nil
+}
+
+func (s *memStore) Truncate(n int) error {
+	s.blocks, s.truncated = s.blocks[:n], true
+	return nil
+}
+
+func TestOpenIgnoresClock(t *testing.T) {
+	w, err := wallet.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesis := DefaultGenesis
+	genesis.Validators = map[string]int{w.Address(): 10}
+	genesis.SlotDuration = 1
+	future := time.Now().Add(time.Hour).UnixNano() // 노드의 시계가 한 시간 늦은 경우
+
+	tests := []struct {
+		name  string
+		block func(parent Block) Block
+		err   error // Append 로 새로 받을 때
+	}{
+		{"future time", func(parent Block) Block {
+			block := NewBlock(parent, BPMPayload(1))
+			block.Timestamp = future
+			block.Hash = CalculateHash(block)
+			return block
+		}, ErrFuture},
+		{"future slot", func(parent Block) Block {
+			block := NewBlock(parent, BPMPayload(1))
+			block.Timestamp = time.Now().Add(time.Duration(genesis.MaxFutureTime-5) * time.Second).UnixNano()
+			block.Validator = w.Address()
+			block.Reveal, block.Commit = RevealPair(w, genesis.ChainID, 0)
+			block.Hash = CalculateHash(block)
+			block.PubKey, block.Signature = w.PublicKey(), w.Sign([]byte(block.Hash))
+			return block
+		}, ErrSlot},
+	}
+	for _, test := range tests {
+		block := test.block(genesis.Block())
+		if err := New(&genesis).Append(block); err != test.err {
+			t.Errorf("%s: Append got %v, want %v", test.name, err, test.err)
+		}
+
+		store := &memStore{blocks: []Block{genesis.Block(), block}}
+		c, err := Open(store, &genesis)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if store.truncated || c.Len() != 2 {
+			t.Errorf("%s: stored block dropped (truncated %v, length %d)", test.name, store.truncated, c.Len())
+		}
+		if report := c.Audit(); !report.Valid {
+			t.Errorf("%s: audit got %+v", test.name, report)
+		}
+	}
+}
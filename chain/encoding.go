@@ -0,0 +1,267 @@
+package chain
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// 헤더 인코딩 버전 - 해쉬 계산 방식이 바뀌면 올림
+// 2: 트랜잭션 대신 헤더의 MerkleRoot 를 해쉬
+// 3: 난이도(0 의 개수) 대신 compact 목표값 Bits
+// 4: 채굴자 주소 Miner 와 채굴 보상 Reward
+// 5: uncle 목록의 해쉬 UnclesHash
+// 6: PoS 제안자의 비밀값 약속 Commit 과 공개 Reveal
+const HeaderVersion = 6
+
+var (
+	ErrVersion  = errors.New("block has unknown header version")
+	ErrEncoding = errors.New("block encoding is malformed")
+)
+
+// 필드마다 고정 길이 정수 또는 길이(4바이트)를 앞에 붙인 바이트로 이어 붙이는 인코더
+// -> 필드 경계가 모호하지 않아 서로 다른 블록이 같은 해쉬 입력을 만들 수 없음
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) uint32(v uint32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	e.buf = append(e.buf, b[:]...)
+}
+
+func (e *encoder) int64(v int64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(v))
+	e.buf = append(e.buf, b[:]...)
+}
+
+func (e *encoder) int(v int) {
+	e.int64(int64(v))
+}
+
+func (e *encoder) bytes(b []byte) {
+	e.uint32(uint32(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+func (e *encoder) string(s string) {
+	e.bytes([]byte(s))
+}
+
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || len(d.buf) < n {
+		d.err = ErrEncoding
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *decoder) uint32() uint32 {
+	b := d.next(4)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b)
+}
+
+func (d *decoder) int64() int64 {
+	b := d.next(8)
+	if b == nil {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(b))
+}
+
+func (d *decoder) int() int {
+	return int(d.int64())
+}
+
+func (d *decoder) bytes() []byte {
+	n := d.uint32()
+	b := d.next(int(n))
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
+
+func (d *decoder) string() string {
+	return string(d.bytes())
+}
+
+func (h Header) encode(e *encoder) {
+	e.uint32(uint32(h.Version))
+	e.string(h.ChainID)
+	e.int(h.Index)
+	e.int64(h.Timestamp)
+	e.string(h.PrevHash)
+	e.uint32(uint32(h.Bits))
+	e.string(h.Nonce)
+	e.string(h.Validator)
+	e.string(h.Miner)
+	e.int(h.Reward)
+	e.string(h.MerkleRoot)
+	e.string(h.UnclesHash)
+	e.string(h.Commit)
+	e.string(h.Reveal)
+}
+
+func (h *Header) decode(d *decoder) {
+	h.Version = int(d.uint32())
+	h.ChainID = d.string()
+	h.Index = d.int()
+	h.Timestamp = d.int64()
+	h.PrevHash = d.string()
+	h.Bits = Compact(d.uint32())
+	h.Nonce = d.string()
+	h.Validator = d.string()
+	h.Miner = d.string()
+	h.Reward = d.int()
+	h.MerkleRoot = d.string()
+	h.UnclesHash = d.string()
+	h.Commit = d.string()
+	h.Reveal = d.string()
+}
+
+func (b Block) encodeBody(e *encoder) {
+	e.string(b.Payload.Type)
+	e.bytes(b.Payload.Data)
+	e.uint32(uint32(len(b.Transactions)))
+	for _, tx := range b.Transactions {
+		tx.encode(e, true)
+	}
+	e.uint32(uint32(len(b.Uncles)))
+	for _, uncle := range b.Uncles {
+		uncle.Header.encode(e)
+		e.string(uncle.Payload.Type)
+		e.bytes(uncle.Payload.Data)
+		e.string(uncle.Hash)
+	}
+}
+
+func (b *Block) decodeBody(d *decoder) {
+	b.Payload.Type = d.string()
+	b.Payload.Data = d.bytes()
+	n := d.uint32()
+	if n > MaxTransactions {
+		d.err = ErrTooManyTxs
+		return
+	}
+	b.Transactions = nil
+	for i := uint32(0); i < n && d.err == nil; i++ {
+		var tx Transaction
+		tx.decode(d)
+		b.Transactions = append(b.Transactions, tx)
+	}
+
+	n = d.uint32()
+	if n > MaxUncles {
+		d.err = ErrUncle
+		return
+	}
+	b.Uncles = nil
+	for i := uint32(0); i < n && d.err == nil; i++ {
+		var uncle Uncle
+		uncle.Header.decode(d)
+		uncle.Payload.Type = d.string()
+		uncle.Payload.Data = d.bytes()
+		uncle.Hash = d.string()
+		b.Uncles = append(b.Uncles, uncle)
+	}
+}
+
+func (h Header) MarshalBinary() ([]byte, error) { // 헤더의 정규(canonical) 인코딩
+	var e encoder
+	h.encode(&e)
+	return e.buf, nil
+}
+
+// 해쉬 계산에 쓰이는 입력: 헤더 + 페이로드 (트랜잭션은 헤더의 MerkleRoot 로 포함)
+func hashPreimage(block Block) []byte {
+	var e encoder
+	block.Header.encode(&e)
+	e.string(block.Payload.Type)
+	e.bytes(block.Payload.Data)
+	return e.buf
+}
+
+func (b Block) Preimage() []byte { // CalculateHash 와 PoW 해쉬의 입력
+	return hashPreimage(b)
+}
+
+func (b Block) MarshalBinary() ([]byte, error) { // 저장소와 네트워크 전송에 쓰는 블록 인코딩
+	var e encoder
+	b.Header.encode(&e)
+	b.encodeBody(&e)
+	e.string(b.Hash)
+	e.string(b.TotalWork)
+	e.string(b.PubKey)
+	e.string(b.Signature)
+	return e.buf, nil
+}
+
+func (b *Block) UnmarshalBinary(data []byte) error {
+	d := decoder{buf: data}
+	b.Header.decode(&d)
+	b.decodeBody(&d)
+	b.Hash = d.string()
+	b.TotalWork = d.string()
+	b.PubKey = d.string()
+	b.Signature = d.string()
+	if d.err != nil {
+		return d.err
+	}
+	if len(d.buf) != 0 {
+		return ErrEncoding
+	}
+	return nil
+}
+
+func EncodeBlocks(blocks []Block) []byte { // 체인 전체 인코딩: 블록 수 + 길이를 붙인 블록들
+	var e encoder
+	e.uint32(uint32(len(blocks)))
+	for _, block := range blocks {
+		data, _ := block.MarshalBinary()
+		e.bytes(data)
+	}
+	return e.buf
+}
+
+func DecodeBlocks(data []byte) ([]Block, error) {
+	d := decoder{buf: data}
+	n := d.uint32()
+	if d.err == nil && int(n) > len(d.buf)/4 { // 블록 하나는 최소 4바이트 - 잘못된 개수로 큰 메모리를 잡지 않도록
+		return nil, ErrEncoding
+	}
+
+	blocks := make([]Block, 0, n)
+	for i := uint32(0); i < n && d.err == nil; i++ {
+		var block Block
+		data := d.bytes()
+		if d.err != nil {
+			break
+		}
+		if err := block.UnmarshalBinary(data); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	if len(d.buf) != 0 {
+		return nil, ErrEncoding
+	}
+	return blocks, nil
+}
@@ -0,0 +1,121 @@
+package chain
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/D0hwQ1/Blockchain-With-Go/wallet"
+)
+
+func testBlocks(t *testing.T) []Block {
+	w, err := wallet.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesis := DefaultGenesis.Block()
+
+	stake := NewTransaction(w, Payload{Type: TypeStake, Data: []byte(`{"Action":"bond","Amount":10}`)})
+	html := NewTransaction(w, Payload{Type: TypeJSON, Data: []byte(`{"note":"\u003ca\u003e \u0026 b"}`)})
+
+	pow := NewBlock(genesis, BPMPayload(72), stake, html)
+	pow.Bits = 0x1f0fffff
+	pow.Nonce = "1a2b"
+	pow.Miner = w.Address()
+	pow.Reward = 50
+	pow.Uncles = []Uncle{UncleOf(GenerateBlock(genesis, BPMPayload(60)))}
+	pow.UnclesHash = UnclesHash(pow.Uncles)
+	pow.Hash = CalculateHash(pow)
+
+	pos := NewBlock(genesis, Payload{Type: TypeRaw, Data: []byte{0, 1, 2, 0xff}})
+	pos.Validator = w.Address()
+	pos.Commit = CommitOf("secret")
+	pos.Reveal = "previous"
+	pos.Hash = CalculateHash(pos)
+	pos.PubKey = w.PublicKey()
+	pos.Signature = w.Sign([]byte(pos.Hash))
+
+	return []Block{genesis, pow, pos, GenerateBlock(genesis, Payload{Type: TypeJSON, Data: []byte(`[1,"x"]`)})}
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	for _, block := range testBlocks(t) {
+		data, err := block.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded Block
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			t.Fatalf("block %d: %v", block.Index, err)
+		}
+		if !reflect.DeepEqual(normalize(block), normalize(decoded)) {
+			t.Errorf("block %d: got %+v, want %+v", block.Index, decoded, block)
+		}
+		if CalculateHash(decoded) != block.Hash {
+			t.Errorf("block %d: hash changed after round trip", block.Index)
+		}
+
+		if err := decoded.UnmarshalBinary(append(data, 0)); err != ErrEncoding {
+			t.Errorf("block %d: trailing byte: got %v, want %v", block.Index, err, ErrEncoding)
+		}
+		if err := decoded.UnmarshalBinary(data[:len(data)-1]); err == nil {
+			t.Errorf("block %d: truncated record decoded", block.Index)
+		}
+	}
+}
+
+func TestEncodeBlocks(t *testing.T) {
+	blocks := testBlocks(t)
+	decoded, err := DecodeBlocks(EncodeBlocks(blocks))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != len(blocks) {
+		t.Fatalf("got %d blocks, want %d", len(decoded), len(blocks))
+	}
+	for i := range blocks {
+		if decoded[i].Hash != blocks[i].Hash {
+			t.Errorf("block %d: got hash %s, want %s", i, decoded[i].Hash, blocks[i].Hash)
+		}
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	for _, block := range testBlocks(t) {
+		data, err := json.Marshal(block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded Block
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("block %d: %v", block.Index, err)
+		}
+		if !reflect.DeepEqual(normalize(block), normalize(decoded)) {
+			t.Errorf("block %d: got %+v, want %+v", block.Index, decoded, block)
+		}
+		for i, tx := range decoded.Transactions {
+			if err := tx.Verify(); err != nil {
+				t.Errorf("block %d tx %d: %v", block.Index, i, err)
+			}
+		}
+	}
+}
+
+// nil 과 빈 슬라이스는 인코딩 후 구별되지 않으므로 비교 전에 맞춤
+func normalize(block Block) Block {
+	if len(block.Transactions) == 0 {
+		block.Transactions = nil
+	}
+	if len(block.Uncles) == 0 {
+		block.Uncles = nil
+	}
+	if len(block.Payload.Data) == 0 {
+		block.Payload.Data = nil
+	}
+	for i := range block.Uncles {
+		if len(block.Uncles[i].Payload.Data) == 0 {
+			block.Uncles[i].Payload.Data = nil
+		}
+	}
+	return block
+}
@@ -0,0 +1,142 @@
+package chain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+)
+
+var ErrGenesis = errors.New("blockchain has a different genesis block")
+
+// 모든 노드가 같은 첫 블록을 만들도록 공유하는 설정 (genesis.json)
+type GenesisConfig struct {
+	ChainID    string         // 체인 식별자, 모든 블록 헤더에 포함
+	Timestamp  int64          // 첫 블록의 시간 (Unix 나노초, 고정값)
+	Payload    Payload        // 첫 블록의 데이터
+	Bits       Compact        // PoW 시작 목표값 (compact)
+	Validators map[string]int // PoS 시작 검증자와 bond 된 stake
+	Balances   map[string]int // 시작 잔액
+
+	BlockReward     int // PoW 채굴 보상
+	HalvingInterval int // 몇 블록마다 채굴 보상을 절반으로 줄일 지 (0 이면 줄이지 않음)
+	UnbondingPeriod int // unbond 한 stake 가 몇 블록 뒤에 잔액으로 돌아오는 지
+	SlashPercent    int // 이중 제안한 검증자의 stake 를 몇 % 깎을 지
+	JailPeriod      int // slash 된 검증자를 몇 블록 동안 leader 선출에서 뺄 지
+	SlotDuration    int // PoS slot 길이 (초), slot 마다 제안자 한 명
+	EpochLength     int // PoS epoch 하나의 slot 수, epoch 이 시작할 때 제안자 일정을 정함
+
+	MaxFutureTime  int // 현재 시간보다 몇 초 뒤의 블록까지 받을 지
+	MedianTimeSpan int // 새 블록의 시간은 최근 몇 개 블록 시간의 중앙값보다 커야 하는 지
+
+	RetargetInterval int     // PoW 목표값을 몇 블록마다 조정할 지 (0 이면 조정하지 않음)
+	TargetBlockTime  int     // PoW 목표 블록 간격 (초, 0 이면 조정하지 않음)
+	MaxBits          Compact // PoW 목표값의 최대값 (가장 쉬운 난이도), 이보다 큰 목표값의 블록은 거부
+	PowHash          string  // PoW 해쉬 알고리즘: sha256 (기본), sha256d, blake3, scrypt, argon2id
+}
+
+var DefaultGenesis = GenesisConfig{ // genesis 파일이 없을 때 사용하는 설정
+	ChainID:    "blockchain-with-go",
+	Timestamp:  1654041600000000000, // 2022-06-01 00:00:00 UTC
+	Payload:    BPMPayload(0),
+	Bits:       0x1f0fffff, // 해쉬 앞 3자리(16진수)가 0 인 정도
+	Validators: map[string]int{},
+	Balances:   map[string]int{},
+
+	BlockReward:     50,
+	HalvingInterval: 100,
+	UnbondingPeriod: 10,
+	SlashPercent:    50,
+	JailPeriod:      20,
+	SlotDuration:    10,
+	EpochLength:     10,
+
+	MaxFutureTime:  120,
+	MedianTimeSpan: 11,
+
+	RetargetInterval: 10,
+	TargetBlockTime:  10,
+	MaxBits:          0x1f7fffff,
+	PowHash:          "sha256",
+}
+
+func GenesisFile() string { // genesis 파일 경로 (GENESIS_FILE 환경변수, 기본값 genesis.json)
+	if path := os.Getenv("GENESIS_FILE"); path != "" {
+		return path
+	}
+	return "genesis.json"
+}
+
+func LoadGenesis() (*GenesisConfig, error) { // genesis 파일을 읽어옴
+	genesis := DefaultGenesis
+
+	data, err := os.ReadFile(GenesisFile())
+	if os.IsNotExist(err) {
+		return &genesis, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &genesis); err != nil {
+		return nil, err
+	}
+	return &genesis, nil
+}
+
+// 설정 전체의 정규 인코딩 (map 은 주소 순) - 필드를 추가하면 여기에도 넣어야 genesis 해쉬에 반영됨
+func (g *GenesisConfig) encode(e *encoder) {
+	e.string(g.ChainID)
+	e.int64(g.Timestamp)
+	e.string(g.Payload.Type)
+	e.bytes(g.Payload.Data)
+	e.uint32(uint32(g.Bits))
+	encodeAmounts(e, g.Validators)
+	encodeAmounts(e, g.Balances)
+
+	for _, v := range []int{
+		g.BlockReward, g.HalvingInterval, g.UnbondingPeriod, g.SlashPercent, g.JailPeriod, g.SlotDuration, g.EpochLength,
+		g.MaxFutureTime, g.MedianTimeSpan, g.RetargetInterval, g.TargetBlockTime,
+	} {
+		e.int(v)
+	}
+	e.uint32(uint32(g.MaxBits))
+	e.string(g.PowHash)
+}
+
+func encodeAmounts(e *encoder, amounts map[string]int) {
+	addresses := make([]string, 0, len(amounts))
+	for address := range amounts {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	e.uint32(uint32(len(addresses)))
+	for _, address := range addresses {
+		e.string(address)
+		e.int(amounts[address])
+	}
+}
+
+func (g *GenesisConfig) Hash() string { // 설정 전체의 sha256 (genesis 블록의 PrevHash)
+	var e encoder
+	g.encode(&e)
+	h := sha256.Sum256(e.buf)
+	return hex.EncodeToString(h[:])
+}
+
+// 설정으로부터 첫 블록 생성 (같은 설정이면 항상 같은 해쉬)
+// 이전 블록이 없으므로 PrevHash 에 설정 전체의 해쉬를 담음 - 검증자, 잔액, 합의 설정이 다른 노드끼리는 genesis 해쉬가 달라 서로의 체인을 받지 않음
+func (g *GenesisConfig) Block() Block {
+	genesisBlock := Block{}
+	genesisBlock.Version = HeaderVersion
+	genesisBlock.ChainID = g.ChainID
+	genesisBlock.Timestamp = g.Timestamp
+	genesisBlock.PrevHash = g.Hash()
+	genesisBlock.Payload = g.Payload
+	genesisBlock.Bits = g.Bits
+	genesisBlock.Hash = CalculateHash(genesisBlock)
+	genesisBlock.TotalWork = Work(g.Bits).Text(16)
+	return genesisBlock
+}
@@ -0,0 +1,175 @@
+package chain
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrLeader = errors.New("block is not proposed by the elected leader")
+	ErrSlot   = errors.New("block is not in a later slot than its parent or is in a future slot")
+)
+
+// 한 slot 의 제안자
+type SlotLeader struct {
+	Slot   int
+	Epoch  int
+	Leader string // 비어 있으면 선출될 수 있는 stake 가 없어 누구나 제안할 수 있음
+}
+
+func (g *GenesisConfig) Slot(timestamp int64) int { // 시간이 속한 slot (genesis 시간부터 SlotDuration 초마다)
+	duration := int64(g.SlotDuration) * int64(time.Second)
+	if duration < 1 {
+		duration = int64(time.Second)
+	}
+	if timestamp < g.Timestamp {
+		return 0
+	}
+	return int((timestamp - g.Timestamp) / duration)
+}
+
+func (g *GenesisConfig) SlotTime(slot int) time.Time { // slot 이 시작하는 시간
+	duration := time.Duration(g.SlotDuration) * time.Second
+	if duration < 1 {
+		duration = time.Second
+	}
+	return time.Unix(0, g.Timestamp).Add(time.Duration(slot) * duration)
+}
+
+func (g *GenesisConfig) Epoch(slot int) int { // slot 이 속한 epoch (EpochLength 개 slot 마다)
+	if g.EpochLength < 1 {
+		return slot
+	}
+	return slot / g.EpochLength
+}
+
+// epoch 의 제안자 일정을 정하는 seed: epoch 이 시작하기 전까지 섞인 beacon (beacon.go) 과 epoch 번호
+func epochSeed(beacon []byte, epoch int) []byte {
+	h := sha256.Sum256(append(append([]byte{}, beacon...), []byte(":"+strconv.Itoa(epoch))...))
+	return h[:]
+}
+
+func slotSeed(seed []byte, slot int) []byte {
+	h := sha256.Sum256(append(append([]byte{}, seed...), []byte(":"+strconv.Itoa(slot))...))
+	return h[:]
+}
+
+// seed 로 bond 된 stake 에 비례해서 검증자 한 명을 고름 (주소 순으로 누적), stake 가 없으면 빈 문자열
+func Leader(seed []byte, stakes map[string]int) string {
+	addresses := make([]string, 0, len(stakes))
+	total := int64(0)
+	for address, stake := range stakes {
+		if stake > 0 {
+			addresses = append(addresses, address)
+			total += int64(stake)
+		}
+	}
+	if total == 0 {
+		return ""
+	}
+	sort.Strings(addresses)
+
+	ticket := new(big.Int).Mod(new(big.Int).SetBytes(seed), big.NewInt(total)).Int64()
+	for _, address := range addresses {
+		ticket -= int64(stakes[address])
+		if ticket < 0 {
+			return address
+		}
+	}
+	return addresses[len(addresses)-1]
+}
+
+// epoch 의 seed 와 stake - 지금 상태의 epoch 이면 저장된 일정, 아니면 지금 상태 (epoch 경계) 에서 새로 정함
+func (s *state) schedule(epoch, height int) ([]byte, map[string]int) {
+	if s.epochSeed != nil && epoch == s.epoch {
+		return s.epochSeed, s.epochStakes
+	}
+	return epochSeed(s.beacon, epoch), s.eligible(height)
+}
+
+// 다음 블록 (height) 이 slot 에 들어갈 때의 제안자
+func (s *state) leader(genesis *GenesisConfig, slot, height int) string {
+	seed, stakes := s.schedule(genesis.Epoch(slot), height)
+	return Leader(slotSeed(seed, slot), stakes)
+}
+
+// 블록을 적용하기 전: 새 epoch 이면 일정을 정하고, 부모와 이 블록 사이에 비어 있는 slot 을 그 제안자의 놓친 slot 으로 셈
+// 한 epoch 보다 긴 공백은 네트워크가 멈췄던 것으로 보고 블록 앞의 한 epoch 만 셈
+func (s *state) enterSlot(genesis *GenesisConfig, block Block) {
+	slot := genesis.Slot(block.Timestamp)
+	if block.Validator != "" {
+		span := genesis.EpochLength
+		if span < 1 {
+			span = 1
+		}
+		from := s.slot + 1
+		if from < slot-span {
+			from = slot - span
+		}
+		for missed := from; missed < slot; missed++ {
+			if leader := s.leader(genesis, missed, block.Index); leader != "" {
+				s.missed[leader]++
+			}
+		}
+	}
+	if epoch := genesis.Epoch(slot); s.epochSeed == nil || epoch != s.epoch {
+		s.epochSeed, s.epochStakes = s.schedule(epoch, block.Index)
+		s.epoch = epoch
+	}
+	s.slot = slot
+}
+
+// 검증자가 제안한 블록은 부모보다 뒤이면서 지금보다 앞선 slot 에 있고, 그 slot 의 일정에 있는 (감옥에 있지 않은) 검증자의 것이어야 함
+// 또 검증자가 앞서 약속한 비밀값을 공개하고 다음 비밀값을 약속해야 함
+func (c *Chain) checkLeader(newBlock Block, st *state, clock bool) error {
+	if newBlock.Validator == "" {
+		return nil
+	}
+	if err := st.checkReveal(newBlock.Validator, newBlock.Reveal, newBlock.Commit); err != nil {
+		return err
+	}
+	slot := c.genesis.Slot(newBlock.Timestamp)
+	if slot <= st.slot || (clock && slot > c.genesis.Slot(time.Now().UnixNano())) {
+		return ErrSlot
+	}
+	if st.jailed[newBlock.Validator] > newBlock.Index {
+		return ErrLeader
+	}
+	leader := st.leader(c.genesis, slot, newBlock.Index)
+	if leader != "" && leader != newBlock.Validator {
+		return ErrLeader
+	}
+	return nil
+}
+
+func (c *Chain) CurrentSlot() SlotLeader { // 지금 시간의 slot 과 그 제안자
+	return c.Schedule(c.genesis.Slot(time.Now().UnixNano()), 1)[0]
+}
+
+// from slot 부터 n 개 slot 의 제안자 - 체인 끝 이후로 블록이 더 추가되지 않는다고 보고 계산
+func (c *Chain) Schedule(from, n int) []SlotLeader {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	height := len(c.blocks)
+	schedule := make([]SlotLeader, 0, n)
+	for slot := from; slot < from+n; slot++ {
+		schedule = append(schedule, SlotLeader{slot, c.genesis.Epoch(slot), c.state.leader(c.genesis, slot, height)})
+	}
+	return schedule
+}
+
+func (c *Chain) Missed() map[string]int { // 검증자별로 제안하지 않고 넘어간 slot 수
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	missed := make(map[string]int, len(c.state.missed))
+	for address, n := range c.state.missed {
+		missed[address] = n
+	}
+	return missed
+}
@@ -0,0 +1,103 @@
+package chain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/D0hwQ1/Blockchain-With-Go/wallet"
+)
+
+func TestLeader(t *testing.T) {
+	stakes := map[string]int{"b": 3, "a": 1, "c": 0}
+	tests := []struct {
+		seed   byte
+		stakes map[string]int
+		want   string
+	}{
+		{0, stakes, "a"}, // 주소 순으로 누적: a [0, 1), b [1, 4)
+		{1, stakes, "b"},
+		{3, stakes, "b"},
+		{4, stakes, "a"}, // 전체 stake 로 나눈 나머지
+		{0, map[string]int{"a": 0}, ""},
+		{0, nil, ""},
+	}
+	for _, test := range tests {
+		for i := 0; i < 10; i++ { // map 순서와 상관없이 같은 결과
+			if got := Leader([]byte{test.seed}, test.stakes); got != test.want {
+				t.Fatalf("seed %d %v: got %q, want %q", test.seed, test.stakes, got, test.want)
+			}
+		}
+	}
+}
+
+func TestScheduleDeterministic(t *testing.T) {
+	genesis := DefaultGenesis
+	genesis.Validators = map[string]int{"a": 5, "b": 5}
+
+	first := New(&genesis).Schedule(0, 100)
+	second := New(&genesis).Schedule(0, 100)
+	counts := make(map[string]int)
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("slot %d: got %+v and %+v", i, first[i], second[i])
+		}
+		if first[i].Epoch != first[i].Slot/genesis.EpochLength {
+			t.Errorf("slot %d: got epoch %d", i, first[i].Epoch)
+		}
+		counts[first[i].Leader]++
+	}
+	if counts["a"] == 0 || counts["b"] == 0 || counts["a"]+counts["b"] != len(first) {
+		t.Errorf("got leaders %v, want both validators and nobody else", counts)
+	}
+}
+
+func TestCheckReveal(t *testing.T) {
+	w, err := wallet.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesis := DefaultGenesis
+	genesis.Validators = map[string]int{w.Address(): 10}
+	genesis.SlotDuration = 1
+
+	propose := func(parent Block, ago time.Duration, reveal, commit string) Block {
+		block := NewBlock(parent, BPMPayload(1))
+		block.Timestamp = time.Now().Add(-ago).UnixNano()
+		block.Validator = w.Address()
+		block.Reveal, block.Commit = reveal, commit
+		block.Hash = CalculateHash(block)
+		block.PubKey, block.Signature = w.PublicKey(), w.Sign([]byte(block.Hash))
+		return block
+	}
+	reveal0, commit0 := RevealPair(w, genesis.ChainID, 0)
+	reveal1, commit1 := RevealPair(w, genesis.ChainID, 1)
+	if reveal0 != "" || CommitOf(reveal1) != commit0 {
+		t.Fatal("round 1 does not reveal the secret committed in round 0")
+	}
+
+	tests := []struct {
+		name           string
+		reveal, commit string // 첫 블록이 commit0 을 약속한 뒤 두 번째 블록
+		err            error
+	}{
+		{"valid", reveal1, commit1, nil},
+		{"wrong reveal", w.Sign([]byte("other")), commit1, ErrReveal},
+		{"missing reveal", "", commit1, ErrReveal},
+		{"commit is not a hash", reveal1, "abc", ErrReveal},
+	}
+	for _, test := range tests {
+		c := New(&genesis)
+		first := propose(c.Tip(), 5*time.Second, "", commit0)
+		if err := c.Append(first); err != nil {
+			t.Fatalf("%s: first block: %v", test.name, err)
+		}
+		if err := c.Append(propose(first, 3*time.Second, test.reveal, test.commit)); err != test.err {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.err)
+		}
+	}
+
+	c := New(&genesis)
+	if err := c.Append(propose(c.Tip(), time.Second, reveal1, commit0)); err != ErrReveal { // 첫 블록은 약속만 함
+		t.Errorf("reveal in the first round: got %v, want %v", err, ErrReveal)
+	}
+}
@@ -0,0 +1,133 @@
+package chain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+)
+
+var (
+	ErrMerkleRoot = errors.New("block has an inconsistent merkle root")
+	ErrNoEntry    = errors.New("block has no such entry")
+)
+
+// 머클 경로의 한 단계: 형제 노드의 해쉬와 그 위치
+type ProofStep struct {
+	Hash string // 형제 노드 해쉬 (hex)
+	Left bool   // 형제 노드가 왼쪽에 있으면 true
+}
+
+// 블록에 트랜잭션 하나가 포함되어 있음을 블록 전체 없이 증명하는 값
+type MerkleProof struct {
+	BlockHash string
+	Root      string // 블록 헤더의 MerkleRoot
+	Index     int    // 트랜잭션 위치
+	Leaf      string // 트랜잭션 해쉬 (Transaction.Hash)
+	Branch    []ProofStep
+}
+
+// 잎과 내부 노드에 서로 다른 접두어를 붙여, 내부 노드를 잎으로 속이는 공격을 막음
+func merkleLeaf(txHash string) []byte {
+	b, _ := hex.DecodeString(txHash)
+	h := sha256.Sum256(append([]byte{0x00}, b...))
+	return h[:]
+}
+
+func merkleNode(left, right []byte) []byte {
+	data := make([]byte, 0, 1+len(left)+len(right))
+	data = append(data, 0x01)
+	data = append(data, left...)
+	data = append(data, right...)
+	h := sha256.Sum256(data)
+	return h[:]
+}
+
+// 머클 트리의 각 층 - 홀수 개인 층은 마지막 노드를 복제해서 짝을 맞춤
+func merkleLevels(txs []Transaction) [][][]byte {
+	level := make([][]byte, 0, len(txs))
+	for _, tx := range txs {
+		level = append(level, merkleLeaf(tx.Hash()))
+	}
+
+	levels := [][][]byte{level}
+	for len(level) > 1 {
+		if len(level)%2 == 1 {
+			level = append(level, level[len(level)-1])
+			levels[len(levels)-1] = level
+		}
+		next := make([][]byte, 0, len(level)/2)
+		for i := 0; i < len(level); i += 2 {
+			next = append(next, merkleNode(level[i], level[i+1]))
+		}
+		levels = append(levels, next)
+		level = next
+	}
+	return levels
+}
+
+func MerkleRoot(txs []Transaction) string { // 트랜잭션이 없으면 빈 문자열
+	if len(txs) == 0 {
+		return ""
+	}
+	levels := merkleLevels(txs)
+	return hex.EncodeToString(levels[len(levels)-1][0])
+}
+
+func (b Block) Proof(index int) (MerkleProof, error) { // index 번째 트랜잭션의 머클 경로
+	if index < 0 || index >= len(b.Transactions) {
+		return MerkleProof{}, ErrNoEntry
+	}
+
+	proof := MerkleProof{
+		BlockHash: b.Hash,
+		Root:      b.MerkleRoot,
+		Index:     index,
+		Leaf:      b.Transactions[index].Hash(),
+		Branch:    []ProofStep{},
+	}
+
+	levels := merkleLevels(b.Transactions)
+	for _, level := range levels[:len(levels)-1] {
+		if index%2 == 0 {
+			proof.Branch = append(proof.Branch, ProofStep{hex.EncodeToString(level[index+1]), false})
+		} else {
+			proof.Branch = append(proof.Branch, ProofStep{hex.EncodeToString(level[index-1]), true})
+		}
+		index /= 2
+	}
+	return proof, nil
+}
+
+func (b Block) EntryProof(entry string) (MerkleProof, error) { // entry 는 트랜잭션 위치 또는 트랜잭션 해쉬
+	if index, err := strconv.Atoi(entry); err == nil {
+		return b.Proof(index)
+	}
+	for i, tx := range b.Transactions {
+		if tx.Hash() == entry {
+			return b.Proof(i)
+		}
+	}
+	return MerkleProof{}, ErrNoEntry
+}
+
+// 클라이언트가 블록 없이 (오프라인으로) 트랜잭션 포함 여부를 확인
+func VerifyProof(txHash string, branch []ProofStep, root string) bool {
+	node := merkleLeaf(txHash)
+	for _, step := range branch {
+		sibling, err := hex.DecodeString(step.Hash)
+		if err != nil || len(sibling) != sha256.Size {
+			return false
+		}
+		if step.Left {
+			node = merkleNode(sibling, node)
+		} else {
+			node = merkleNode(node, sibling)
+		}
+	}
+	return hex.EncodeToString(node) == root
+}
+
+func (p MerkleProof) Verify() bool {
+	return VerifyProof(p.Leaf, p.Branch, p.Root)
+}
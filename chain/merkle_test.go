@@ -0,0 +1,93 @@
+package chain
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/D0hwQ1/Blockchain-With-Go/wallet"
+)
+
+func TestMerkleProof(t *testing.T) {
+	w, err := wallet.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var txs []Transaction
+	for i := 0; i < 7; i++ {
+		txs = append(txs, NewTransaction(w, BPMPayload(60+i)))
+	}
+
+	tests := []struct {
+		leaves int
+		depth  int
+	}{
+		{1, 0},
+		{2, 1},
+		{3, 2}, // 홀수 개: 마지막 잎을 복제
+		{5, 3},
+		{6, 3}, // 두 번째 층이 홀수 개
+		{7, 3},
+	}
+	for _, test := range tests {
+		block := GenerateBlock(DefaultGenesis.Block(), BPMPayload(72), txs[:test.leaves]...)
+		for i := 0; i < test.leaves; i++ {
+			proof, err := block.Proof(i)
+			if err != nil {
+				t.Fatalf("%d leaves, index %d: %v", test.leaves, i, err)
+			}
+			if len(proof.Branch) != test.depth {
+				t.Errorf("%d leaves, index %d: got depth %d, want %d", test.leaves, i, len(proof.Branch), test.depth)
+			}
+			if !proof.Verify() {
+				t.Errorf("%d leaves, index %d: proof does not verify", test.leaves, i)
+			}
+			if proof.Root != block.MerkleRoot || proof.Leaf != block.Transactions[i].Hash() {
+				t.Errorf("%d leaves, index %d: proof does not match the block", test.leaves, i)
+			}
+
+			byHash, err := block.EntryProof(proof.Leaf)
+			if err != nil || byHash.Index != i {
+				t.Errorf("%d leaves: entry %s: got index %d, %v", test.leaves, proof.Leaf, byHash.Index, err)
+			}
+			if other := (i + 1) % test.leaves; other != i && VerifyProof(txs[other].Hash(), proof.Branch, proof.Root) {
+				t.Errorf("%d leaves, index %d: proof verifies transaction %d", test.leaves, i, other)
+			}
+		}
+
+		if _, err := block.Proof(test.leaves); err != ErrNoEntry {
+			t.Errorf("%d leaves: out of range: got %v, want %v", test.leaves, err, ErrNoEntry)
+		}
+		if _, err := block.EntryProof(strconv.Itoa(-1)); err != ErrNoEntry {
+			t.Errorf("%d leaves: negative index: got %v, want %v", test.leaves, err, ErrNoEntry)
+		}
+	}
+}
+
+func TestMerkleProofTampered(t *testing.T) {
+	w, err := wallet.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	txs := []Transaction{NewTransaction(w, BPMPayload(1)), NewTransaction(w, BPMPayload(2)), NewTransaction(w, BPMPayload(3))}
+	block := GenerateBlock(DefaultGenesis.Block(), BPMPayload(72), txs...)
+	proof, _ := block.Proof(2)
+
+	tests := []struct {
+		name   string
+		tamper func(p *MerkleProof)
+	}{
+		{"side", func(p *MerkleProof) { p.Branch[1].Left = !p.Branch[1].Left }}, // 첫 단계는 복제된 자기 자신이라 위치가 상관없음
+		{"sibling", func(p *MerkleProof) { p.Branch[1].Hash = p.Branch[0].Hash }},
+		{"short", func(p *MerkleProof) { p.Branch = p.Branch[:1] }},
+		{"bad hex", func(p *MerkleProof) { p.Branch[0].Hash = "zz" }},
+		{"root as leaf", func(p *MerkleProof) { p.Leaf, p.Branch = p.Root, nil }}, // 잎과 내부 노드는 접두어가 다름
+	}
+	for _, test := range tests {
+		p := proof
+		p.Branch = append([]ProofStep{}, proof.Branch...)
+		test.tamper(&p)
+		if p.Verify() {
+			t.Errorf("%s: tampered proof verifies", test.name)
+		}
+	}
+}
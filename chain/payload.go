@@ -0,0 +1,158 @@
+package chain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// 페이로드 종류 (content-type 태그)
+const (
+	TypeBPM   = "bpm"                      // 기본 제공: BPM 정수
+	TypeJSON  = "application/json"         // 임의의 JSON
+	TypeRaw   = "application/octet-stream" // 임의의 바이트
+	TypeStake = "stake"                    // 기본 제공: stake bond/unbond (트랜잭션 전용)
+	TypeSlash = "slash"                    // 기본 제공: 이중 제안 증거 (트랜잭션 전용)
+)
+
+const MaxPayloadSize = 1 << 16 // 블록 하나에 담을 수 있는 페이로드 크기
+
+var ErrPayload = errors.New("block has an invalid payload")
+
+// 블록이 담는 데이터 - Type 으로 Data 를 어떻게 해석할 지 정함
+type Payload struct {
+	Type string
+	Data []byte
+}
+
+func BPMPayload(bpm int) Payload {
+	return Payload{Type: TypeBPM, Data: []byte(strconv.Itoa(bpm))}
+}
+
+func (p Payload) BPM() (int, bool) { // BPM 페이로드면 값을 꺼냄 ("007", "+7" 처럼 JSON 숫자가 아닌 표기는 거부)
+	if p.Type != TypeBPM {
+		return 0, false
+	}
+	bpm, err := strconv.Atoi(string(p.Data))
+	return bpm, err == nil && strconv.Itoa(bpm) == string(p.Data)
+}
+
+func (p Payload) Validate() error {
+	if len(p.Data) > MaxPayloadSize {
+		return ErrPayload
+	}
+	switch p.Type {
+	case TypeBPM:
+		if _, ok := p.BPM(); !ok {
+			return ErrPayload
+		}
+	case TypeJSON:
+		if !isCanonical(p.Data) {
+			return ErrPayload
+		}
+	case TypeStake:
+		if _, ok := p.Stake(); !ok || !isCanonical(p.Data) {
+			return ErrPayload
+		}
+	case TypeSlash:
+		if !isCanonical(p.Data) {
+			return ErrPayload
+		}
+		if _, ok := p.Evidence(); !ok {
+			return ErrEvidence
+		}
+	case TypeRaw:
+	default:
+		return ErrPayload
+	}
+	return nil
+}
+
+// JSON 페이로드의 정규형: 공백을 없애고 encoding/json 처럼 <, >, & 를 \u003c 등으로 이스케이프
+// 정규형이면 JSON 으로 주고받아도 바이트 (블록 해쉬, 트랜잭션 서명) 가 바뀌지 않음
+func canonicalJSON(data []byte) ([]byte, bool) {
+	var compact, escaped bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return nil, false
+	}
+	json.HTMLEscape(&escaped, compact.Bytes())
+	return escaped.Bytes(), true
+}
+
+func isCanonical(data []byte) bool {
+	canonical, ok := canonicalJSON(data)
+	return ok && bytes.Equal(canonical, data)
+}
+
+// JSON 으로 볼 때: bpm / JSON / stake / slash 페이로드는 값 그대로 (정규형이라 다시 읽어도 같은 바이트), 바이트 페이로드는 base64 문자열로 표시
+func (p Payload) MarshalJSON() ([]byte, error) {
+	var data interface{} = p.Data
+	if p.Type == TypeBPM || p.Type == TypeJSON || p.Type == TypeStake || p.Type == TypeSlash {
+		data = json.RawMessage(p.Data)
+	}
+	return json.Marshal(struct {
+		Type string
+		Data interface{}
+	}{p.Type, data})
+}
+
+func (p *Payload) UnmarshalJSON(b []byte) error {
+	var msg struct {
+		Type string
+		Data json.RawMessage
+	}
+	if err := json.Unmarshal(b, &msg); err != nil {
+		return err
+	}
+
+	payload, err := Message{Type: msg.Type, Data: msg.Data}.Payload()
+	if err != nil {
+		return err
+	}
+	*p = payload
+	return nil
+}
+
+// POST / 요청 본문: 기존 {"BPM": 72} 또는 {"Type": "application/json", "Data": {...}}
+type Message struct {
+	BPM  int
+	Type string          // 비어 있으면 BPM 페이로드
+	Data json.RawMessage // Type 이 application/octet-stream 이면 base64 문자열
+}
+
+func (m Message) Payload() (Payload, error) {
+	var payload Payload
+
+	switch m.Type {
+	case "":
+		return BPMPayload(m.BPM), nil
+	case TypeRaw:
+		payload.Type = TypeRaw
+		if err := json.Unmarshal(m.Data, &payload.Data); err != nil {
+			return payload, err
+		}
+	default:
+		data, ok := canonicalJSON(m.Data)
+		if !ok {
+			return payload, ErrPayload
+		}
+		payload.Type, payload.Data = m.Type, data
+	}
+	return payload, payload.Validate()
+}
+
+// 콘솔/tcp 한 줄 입력을 페이로드로 변환: 정수면 BPM, JSON 이면 JSON, 나머지는 바이트 그대로
+func ParsePayload(line string) Payload {
+	line = strings.TrimSpace(line)
+	if bpm, err := strconv.Atoi(line); err == nil {
+		return BPMPayload(bpm)
+	}
+	if strings.HasPrefix(line, "{") || strings.HasPrefix(line, "[") {
+		if data, ok := canonicalJSON([]byte(line)); ok {
+			return Payload{Type: TypeJSON, Data: data}
+		}
+	}
+	return Payload{Type: TypeRaw, Data: []byte(line)}
+}
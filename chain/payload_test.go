@@ -0,0 +1,55 @@
+package chain
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestPayloadJSON(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Payload
+		err  bool
+	}{
+		{`{"Type":"bpm","Data":72}`, BPMPayload(72), false},
+		{`{"Type":"bpm","Data":007}`, Payload{}, true},
+		{`{"Type":"application/json","Data":{"b": 1, "a": [1, 2]}}`, Payload{TypeJSON, []byte(`{"b":1,"a":[1,2]}`)}, false},
+		{`{"Type":"application/json","Data":{"x":"<&>"}}`, Payload{TypeJSON, []byte(`{"x":"\u003c\u0026\u003e"}`)}, false}, // 정규형은 HTML 문자를 이스케이프
+		{`{"Type":"application/octet-stream","Data":"AAH/"}`, Payload{TypeRaw, []byte{0, 1, 0xff}}, false},
+		{`{"Type":"application/octet-stream","Data":"!"}`, Payload{}, true},
+	}
+	for _, test := range tests {
+		var p Payload
+		err := json.Unmarshal([]byte(test.in), &p)
+		if (err != nil) != test.err {
+			t.Errorf("%s: got error %v", test.in, err)
+			continue
+		}
+		if err == nil && (p.Type != test.want.Type || !bytes.Equal(p.Data, test.want.Data)) {
+			t.Errorf("%s: got %s %q, want %s %q", test.in, p.Type, p.Data, test.want.Type, test.want.Data)
+		}
+	}
+}
+
+func TestPayloadValidate(t *testing.T) {
+	tests := []struct {
+		payload Payload
+		valid   bool
+	}{
+		{BPMPayload(72), true},
+		{Payload{TypeBPM, []byte("007")}, false},
+		{Payload{TypeBPM, []byte("+7")}, false},
+		{Payload{TypeJSON, []byte(`{"a":1}`)}, true},
+		{Payload{TypeJSON, []byte(`{"a": 1}`)}, false}, // 정규형이 아님
+		{Payload{TypeJSON, []byte(`{"x":"<"}`)}, false},
+		{Payload{TypeJSON, []byte(`{`)}, false},
+		{Payload{TypeRaw, []byte{0xff}}, true},
+		{Payload{TypeRaw, make([]byte, MaxPayloadSize+1)}, false},
+	}
+	for _, test := range tests {
+		if err := test.payload.Validate(); (err == nil) != test.valid {
+			t.Errorf("%s %q: got %v, want valid %v", test.payload.Type, test.payload.Data, err, test.valid)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package chain
+
+import (
+	"errors"
+
+	"github.com/D0hwQ1/Blockchain-With-Go/wallet"
+)
+
+var ErrReward = errors.New("block has an invalid coinbase reward")
+
+func (g *GenesisConfig) Reward(height int) int { // height 번째 블록의 채굴 보상: HalvingInterval 블록마다 절반
+	if g.HalvingInterval < 1 {
+		return g.BlockReward
+	}
+	halvings := height / g.HalvingInterval
+	if halvings >= 63 {
+		return 0
+	}
+	return g.BlockReward >> uint(halvings)
+}
+
+// 채굴자 주소가 있는 블록은 일정에 맞는 보상만, 없는 블록은 보상 없음
+func (c *Chain) checkReward(newBlock Block) error {
+	if newBlock.Miner == "" {
+		if newBlock.Reward != 0 {
+			return ErrReward
+		}
+		return nil
+	}
+	if !wallet.IsAddress(newBlock.Miner) || newBlock.Reward != c.genesis.Reward(newBlock.Index) {
+		return ErrReward
+	}
+	return nil
+}
+
+// 블록의 보상을 채굴자 잔액에 더함 - uncle 을 담았으면 uncle 채굴자 보상과 조카 블록 채굴자의 추가 보상도
+func (s *state) credit(genesis *GenesisConfig, block Block) {
+	if block.Miner != "" && block.Reward != 0 {
+		s.balances[block.Miner] += block.Reward + len(block.Uncles)*genesis.NephewReward(block.Index)
+	}
+	for _, uncle := range block.Uncles {
+		if uncle.Miner != "" {
+			s.balances[uncle.Miner] += genesis.UncleReward(block.Index, uncle)
+		}
+	}
+}
+
+func (c *Chain) Balance(address string) int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.state.balances[address]
+}
+
+func (c *Chain) Balances() map[string]int { // genesis 시작 잔액 + 채굴 보상 - bond 한 stake
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	balances := make(map[string]int, len(c.state.balances))
+	for address, balance := range c.state.balances {
+		balances[address] = balance
+	}
+	return balances
+}
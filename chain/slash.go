@@ -0,0 +1,131 @@
+package chain
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/D0hwQ1/Blockchain-With-Go/wallet"
+)
+
+const SlashReporterShare = 10 // 깎인 stake 중 증거를 낸 주소가 받는 비율 (%), 나머지는 소각
+
+var (
+	ErrEvidence = errors.New("transaction has invalid equivocation evidence")
+	ErrSlashed  = errors.New("validator is already slashed for this slot")
+)
+
+// 검증자가 서명한 블록 헤더 - 트랜잭션 없이 해쉬와 서명을 확인할 수 있음
+type Proposal struct {
+	Header
+	Payload   Payload
+	Hash      string
+	PubKey    string
+	Signature string
+}
+
+func ProposalOf(block Block) Proposal {
+	return Proposal{block.Header, block.Payload, block.Hash, block.PubKey, block.Signature}
+}
+
+func (p Proposal) Verify() error { // 해쉬가 헤더와 맞고 Validator 의 키로 서명되었는지
+	if p.Validator == "" || CalculateHash(Block{Header: p.Header, Payload: p.Payload}) != p.Hash {
+		return ErrEvidence
+	}
+	if err := wallet.VerifyAddress(p.Validator, p.PubKey, []byte(p.Hash), p.Signature); err != nil {
+		return ErrEvidence
+	}
+	return nil
+}
+
+// 이중 제안 증거: 같은 검증자가 같은 slot, 같은 높이에 서명한 서로 다른 두 블록
+type Evidence struct {
+	First  Proposal
+	Second Proposal
+}
+
+func (e Evidence) Offender() string {
+	return e.First.Validator
+}
+
+func (e Evidence) Verify() error {
+	if e.First.Validator != e.Second.Validator || e.First.ChainID != e.Second.ChainID || e.First.Index != e.Second.Index {
+		return ErrEvidence
+	}
+	if e.First.Hash == e.Second.Hash {
+		return ErrEvidence
+	}
+	if err := e.First.Verify(); err != nil {
+		return err
+	}
+	return e.Second.Verify()
+}
+
+func SlashPayload(evidence Evidence) Payload {
+	data, _ := json.Marshal(evidence)
+	return Payload{Type: TypeSlash, Data: data}
+}
+
+func (p Payload) Evidence() (Evidence, bool) { // slash 페이로드면 증거를 꺼냄
+	var evidence Evidence
+	if p.Type != TypeSlash || json.Unmarshal(p.Data, &evidence) != nil {
+		return evidence, false
+	}
+	return evidence, evidence.Verify() == nil
+}
+
+// 증거의 검증자 stake (bond 된 것과 unbonding 중인 것) 를 SlashPercent 만큼 깎고 JailPeriod 블록 동안 leader 선출에서 뺌
+func (s *state) applySlash(genesis *GenesisConfig, height int, tx Transaction) error {
+	evidence, ok := tx.Payload.Evidence()
+	if !ok || evidence.First.ChainID != genesis.ChainID || evidence.First.Index > height {
+		return ErrEvidence
+	}
+	slot := genesis.Slot(evidence.First.Timestamp)
+	if slot != genesis.Slot(evidence.Second.Timestamp) { // 놓친 slot 뒤에 같은 높이를 다시 제안하는 것은 위반이 아님
+		return ErrEvidence
+	}
+	offender := evidence.Offender()
+	key := offender + ":" + strconv.Itoa(slot) // 같은 위반으로 두 번 slash 하지 않음
+	if s.slashed[key] {
+		return ErrSlashed
+	}
+
+	slashed := s.bonded[offender] * genesis.SlashPercent / 100
+	s.bonded[offender] -= slashed
+	if s.bonded[offender] == 0 {
+		delete(s.bonded, offender)
+	}
+	for i, u := range s.unbonding {
+		if u.Address == offender {
+			cut := u.Amount * genesis.SlashPercent / 100
+			s.unbonding[i].Amount -= cut
+			slashed += cut
+		}
+	}
+
+	s.balances[tx.From] += slashed * SlashReporterShare / 100
+	s.slashed[key] = true
+	s.jailed[offender] = height + genesis.JailPeriod
+	return nil
+}
+
+// height 번째 블록의 leader 후보: 감옥에 있지 않은 검증자의 bond 된 stake
+func (s *state) eligible(height int) map[string]int {
+	stakes := make(map[string]int, len(s.bonded))
+	for address, stake := range s.bonded {
+		if s.jailed[address] <= height {
+			stakes[address] = stake
+		}
+	}
+	return stakes
+}
+
+func (c *Chain) Jailed(address string) int { // leader 선출에 다시 들어가는 높이 (감옥에 있지 않으면 0)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if release := c.state.jailed[address]; release > len(c.blocks) {
+		return release
+	}
+	return 0
+}
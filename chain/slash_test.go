@@ -0,0 +1,74 @@
+package chain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/D0hwQ1/Blockchain-With-Go/wallet"
+)
+
+func TestApplySlash(t *testing.T) {
+	offender, err := wallet.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reporter, err := wallet.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesis := DefaultGenesis
+	genesis.Validators = map[string]int{offender.Address(): 100}
+	other := genesis
+	other.ChainID = "other"
+	slotTime := func(slot int) int64 { return genesis.SlotTime(slot).UnixNano() }
+
+	proposalOn := func(genesis *GenesisConfig, bpm int, timestamp int64) Proposal {
+		block := NewBlock(genesis.Block(), BPMPayload(bpm))
+		block.Validator = offender.Address()
+		block.Timestamp = timestamp
+		block.Hash = CalculateHash(block)
+		block.Sign(offender)
+		return ProposalOf(block)
+	}
+	proposal := func(bpm int, timestamp int64) Proposal { return proposalOn(&genesis, bpm, timestamp) }
+	first := proposal(1, slotTime(3))
+
+	s := newState(&genesis)
+	s.unbonding = []Unbonding{{offender.Address(), 40, 30}}
+
+	tests := []struct {
+		name     string
+		evidence Evidence
+		height   int
+		err      error
+		bonded   int // slash 를 적용한 뒤
+		unbonded int
+		reward   int // 증거를 낸 주소의 잔액
+		jailed   int
+	}{
+		{"same block", Evidence{first, first}, 5, ErrEvidence, 100, 40, 0, 0},
+		{"different slots", Evidence{first, proposal(2, slotTime(4))}, 5, ErrEvidence, 100, 40, 0, 0},
+		{"other chain", Evidence{proposalOn(&other, 1, slotTime(3)), proposalOn(&other, 2, slotTime(3))}, 5, ErrEvidence, 100, 40, 0, 0},
+		{"future height", Evidence{first, proposal(2, slotTime(3)+int64(time.Second))}, 0, ErrEvidence, 100, 40, 0, 0},
+		{"equivocation", Evidence{first, proposal(2, slotTime(3)+int64(time.Second))}, 5, nil, 50, 20, 7, 25},
+		{"slashed again", Evidence{first, proposal(3, slotTime(3))}, 6, ErrSlashed, 50, 20, 7, 25},
+	}
+	for _, test := range tests {
+		tx := NewTransaction(reporter, SlashPayload(test.evidence))
+		if err := s.applySlash(&genesis, test.height, tx); err != test.err {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.err)
+		}
+		if bonded := s.bonded[offender.Address()]; bonded != test.bonded {
+			t.Errorf("%s: bonded %d, want %d", test.name, bonded, test.bonded)
+		}
+		if unbonded := s.unbonding[0].Amount; unbonded != test.unbonded {
+			t.Errorf("%s: unbonding %d, want %d", test.name, unbonded, test.unbonded)
+		}
+		if reward := s.balances[reporter.Address()]; reward != test.reward {
+			t.Errorf("%s: reporter balance %d, want %d", test.name, reward, test.reward)
+		}
+		if jailed := s.jailed[offender.Address()]; jailed != test.jailed {
+			t.Errorf("%s: jailed until %d, want %d", test.name, jailed, test.jailed)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package chain
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// stake 트랜잭션 동작
+const (
+	StakeBond   = "bond"   // 잔액을 stake 로 묶음
+	StakeUnbond = "unbond" // stake 를 풀어 UnbondingPeriod 블록 뒤에 잔액으로 돌려받음
+)
+
+var (
+	ErrStake          = errors.New("transaction has an invalid stake action")
+	ErrBalance        = errors.New("insufficient balance to bond")
+	ErrBonded         = errors.New("insufficient bonded stake to unbond")
+	ErrStakeInPayload = errors.New("block payload cannot carry a stake or slash action")
+)
+
+// stake 페이로드 (TypeStake) 의 데이터 - 서명된 트랜잭션으로만 보낼 수 있음
+type Stake struct {
+	Action string
+	Amount int
+}
+
+// unbond 한 stake - Release 높이의 블록이 추가될 때 Address 의 잔액으로 돌아감
+type Unbonding struct {
+	Address string
+	Amount  int
+	Release int
+}
+
+func StakePayload(action string, amount int) Payload {
+	data, _ := json.Marshal(Stake{action, amount})
+	return Payload{Type: TypeStake, Data: data}
+}
+
+func (p Payload) Stake() (Stake, bool) { // stake 페이로드면 값을 꺼냄
+	var stake Stake
+	if p.Type != TypeStake || json.Unmarshal(p.Data, &stake) != nil {
+		return stake, false
+	}
+	if (stake.Action != StakeBond && stake.Action != StakeUnbond) || stake.Amount <= 0 {
+		return stake, false
+	}
+	return stake, true
+}
+
+func (s *state) release(height int) { // height 에서 풀리는 unbonding stake 를 잔액으로
+	pending := s.unbonding[:0]
+	for _, u := range s.unbonding {
+		if u.Release <= height {
+			s.balances[u.Address] += u.Amount
+			continue
+		}
+		pending = append(pending, u)
+	}
+	s.unbonding = pending
+}
+
+func (s *state) applyStake(genesis *GenesisConfig, height int, tx Transaction) error {
+	stake, ok := tx.Payload.Stake()
+	if !ok {
+		return ErrStake
+	}
+
+	switch stake.Action {
+	case StakeBond:
+		if s.balances[tx.From] < stake.Amount {
+			return ErrBalance
+		}
+		s.balances[tx.From] -= stake.Amount
+		s.bonded[tx.From] += stake.Amount
+	case StakeUnbond:
+		if s.bonded[tx.From] < stake.Amount {
+			return ErrBonded
+		}
+		s.bonded[tx.From] -= stake.Amount
+		if s.bonded[tx.From] == 0 {
+			delete(s.bonded, tx.From)
+		}
+		s.unbonding = append(s.unbonding, Unbonding{tx.From, stake.Amount, height + genesis.UnbondingPeriod})
+	}
+	return nil
+}
+
+// 다음 블록에 차례로 담았을 때 유효한 트랜잭션만 (잔액이 모자란 stake 트랜잭션은 뒤 블록을 위해 남겨 둠,)
+func (c *Chain) Applicable(txs []Transaction) []Transaction {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	height := len(c.blocks)
+	st := c.state.clone()
+	st.release(height)
+
+	applicable := make([]Transaction, 0, len(txs))
+	for _, tx := range txs {
+		if st.applyTx(c.genesis, height, tx) == nil { // 실패하면 상태를 바꾸지 않음
+			applicable = append(applicable, tx)
+		}
+	}
+	return applicable
+}
+
+func (c *Chain) Stake(address string) int { // bond 된 stake
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.state.bonded[address]
+}
+
+func (c *Chain) Stakes() map[string]int { // 주소별 bond 된 stake (PoS 검증자 가중치)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	stakes := make(map[string]int, len(c.state.bonded))
+	for address, stake := range c.state.bonded {
+		stakes[address] = stake
+	}
+	return stakes
+}
+
+func (c *Chain) Unbonding(address string) []Unbonding { // 잔액으로 돌아오기를 기다리는 stake
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var unbonding []Unbonding
+	for _, u := range c.state.unbonding {
+		if u.Address == address {
+			unbonding = append(unbonding, u)
+		}
+	}
+	return unbonding
+}
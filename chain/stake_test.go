@@ -0,0 +1,55 @@
+package chain
+
+import (
+	"testing"
+
+	"github.com/D0hwQ1/Blockchain-With-Go/wallet"
+)
+
+func TestStakeRelease(t *testing.T) {
+	w, err := wallet.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesis := DefaultGenesis
+	genesis.Balances = map[string]int{w.Address(): 100}
+	genesis.UnbondingPeriod = 3
+	c := New(&genesis)
+
+	tests := []struct {
+		action  string // 비어 있으면 트랜잭션 없는 블록
+		amount  int
+		err     error
+		balance int // 블록을 추가한 뒤
+		stake   int
+	}{
+		{StakeBond, 101, ErrBalance, 100, 0},
+		{StakeBond, 10, nil, 90, 10}, // 높이 1
+		{StakeUnbond, 11, ErrBonded, 90, 10},
+		{StakeUnbond, 4, nil, 90, 6}, // 높이 2, 높이 5 에 풀림
+		{"", 0, nil, 90, 6},          // 높이 3
+		{"", 0, nil, 90, 6},          // 높이 4
+		{"", 0, nil, 94, 6},          // 높이 5
+		{StakeUnbond, 6, nil, 94, 0}, // 높이 6, 높이 9 에 풀림
+	}
+	for i, test := range tests {
+		var txs []Transaction
+		if test.action != "" {
+			txs = append(txs, NewTransaction(w, StakePayload(test.action, test.amount)))
+		}
+		if err := c.Append(GenerateBlock(c.Tip(), BPMPayload(i), txs...)); err != test.err {
+			t.Fatalf("step %d: got %v, want %v", i, err, test.err)
+		}
+		if balance, stake := c.Balance(w.Address()), c.Stake(w.Address()); balance != test.balance || stake != test.stake {
+			t.Errorf("step %d: got balance %d stake %d, want %d %d", i, balance, stake, test.balance, test.stake)
+		}
+	}
+
+	unbonding := c.Unbonding(w.Address())
+	if len(unbonding) != 1 || unbonding[0].Amount != 6 || unbonding[0].Release != 9 {
+		t.Errorf("got unbonding %+v, want 6 released at height 9", unbonding)
+	}
+	if _, ok := c.Stakes()[w.Address()]; ok {
+		t.Error("fully unbonded address is still listed in Stakes")
+	}
+}
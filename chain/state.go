@@ -0,0 +1,117 @@
+package chain
+
+// 블록을 차례로 적용하며 쌓이는 체인 상태 - 담긴 트랜잭션, 잔액, staking, slashing, PoS slot 일정
+type state struct {
+	included  map[string]bool // 체인에 담긴 트랜잭션 해쉬
+	balances  map[string]int  // 주소별 잔액
+	bonded    map[string]int  // 주소별 bond 된 stake
+	unbonding []Unbonding     // 잔액으로 돌아오기를 기다리는 stake
+	slashed   map[string]bool // 이미 slash 한 이중 제안 (검증자:slot)
+	jailed    map[string]int  // 주소별 leader 선출에 다시 들어가는 높이
+
+	beacon      []byte            // 검증자가 공개한 비밀값을 섞은 값 (epoch seed, 바꾸지 않고 새로 만들므로 복사본과 공유)
+	commits     map[string]string // 검증자별로 다음에 공개할 비밀값의 약속
+	rounds      map[string]int    // 검증자별로 약속한 비밀값 수
+	slot        int               // 마지막으로 적용한 블록의 slot
+	epoch       int               // 마지막으로 적용한 블록의 epoch
+	epochSeed   []byte            // epoch 의 제안자 일정 seed
+	epochStakes map[string]int    // epoch 이 시작할 때의 선출될 수 있는 stake (바꾸지 않으므로 복사본과 공유)
+	missed      map[string]int    // 검증자별로 놓친 slot 수
+}
+
+func newState(genesis *GenesisConfig) *state { // genesis 의 시작 잔액과 검증자 stake
+	s := &state{
+		included: make(map[string]bool),
+		balances: make(map[string]int),
+		bonded:   make(map[string]int),
+		slashed:  make(map[string]bool),
+		jailed:   make(map[string]int),
+		missed:   make(map[string]int),
+		commits:  make(map[string]string),
+		rounds:   make(map[string]int),
+	}
+	for address, balance := range genesis.Balances {
+		s.balances[address] = balance
+	}
+	for address, stake := range genesis.Validators {
+		s.bonded[address] = stake
+	}
+	return s
+}
+
+func (s *state) clone() *state { // 블록을 검증할 때 실제 상태를 바꾸지 않도록 복사
+	c := &state{
+		included:  nil, // 트랜잭션 색인은 복사하지 않음 - 복사본에는 transition 만 적용
+		balances:  make(map[string]int, len(s.balances)),
+		bonded:    make(map[string]int, len(s.bonded)),
+		unbonding: append([]Unbonding{}, s.unbonding...),
+		slashed:   make(map[string]bool, len(s.slashed)),
+		jailed:    make(map[string]int, len(s.jailed)),
+
+		beacon:      s.beacon,
+		commits:     make(map[string]string, len(s.commits)),
+		rounds:      make(map[string]int, len(s.rounds)),
+		slot:        s.slot,
+		epoch:       s.epoch,
+		epochSeed:   s.epochSeed,
+		epochStakes: s.epochStakes,
+		missed:      make(map[string]int, len(s.missed)),
+	}
+	for address, balance := range s.balances {
+		c.balances[address] = balance
+	}
+	for address, stake := range s.bonded {
+		c.bonded[address] = stake
+	}
+	for key := range s.slashed {
+		c.slashed[key] = true
+	}
+	for address, release := range s.jailed {
+		c.jailed[address] = release
+	}
+	for address, n := range s.missed {
+		c.missed[address] = n
+	}
+	for address, commit := range s.commits {
+		c.commits[address] = commit
+	}
+	for address, n := range s.rounds {
+		c.rounds[address] = n
+	}
+	return c
+}
+
+// 블록을 상태에 적용하고 트랜잭션 색인에 추가
+func (s *state) apply(genesis *GenesisConfig, block Block) error {
+	s.enterSlot(genesis, block)
+	s.mixReveal(block)
+	if err := s.transition(genesis, block); err != nil {
+		return err
+	}
+	for _, tx := range block.Transactions {
+		s.included[tx.Hash()] = true
+	}
+	return nil
+}
+
+// 잔액과 stake 변화: unbonding 기간이 끝난 stake 반환, 채굴 보상, stake / slash 트랜잭션 순서
+func (s *state) transition(genesis *GenesisConfig, block Block) error {
+	s.release(block.Index)
+	s.credit(genesis, block)
+	for _, tx := range block.Transactions {
+		if err := s.applyTx(genesis, block.Index, tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *state) applyTx(genesis *GenesisConfig, height int, tx Transaction) error { // 트랜잭션이 상태를 바꾸는 경우만
+	switch tx.Payload.Type {
+	case TypeStake:
+		return s.applyStake(genesis, height, tx)
+	case TypeSlash:
+		return s.applySlash(genesis, height, tx)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package chain
+
+import (
+	"sort"
+	"time"
+)
+
+// 블록 시간 검증: 너무 먼 미래의 블록은 거부하고 (clock 이 true 일 때만), 최근 블록 시간의 중앙값보다 커야 함
+func (c *Chain) checkTime(blocks []Block, newBlock Block, clock bool) error {
+	maxFuture := time.Duration(c.genesis.MaxFutureTime) * time.Second
+	if clock && newBlock.Timestamp > time.Now().Add(maxFuture).UnixNano() {
+		return ErrFuture
+	}
+	if newBlock.Timestamp <= MedianTime(blocks, c.genesis.MedianTimeSpan) {
+		return ErrTimestamp
+	}
+	return nil
+}
+
+func MedianTime(blocks []Block, span int) int64 { // 마지막 span 개 블록 시간의 중앙값
+	if span < 1 {
+		span = 1
+	}
+	if span > len(blocks) {
+		span = len(blocks)
+	}
+
+	times := make([]int64, 0, span)
+	for _, block := range blocks[len(blocks)-span:] {
+		times = append(times, block.Timestamp)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	return times[len(times)/2]
+}
@@ -0,0 +1,96 @@
+package chain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/D0hwQ1/Blockchain-With-Go/wallet"
+)
+
+const MaxTransactions = 100 // 블록 하나에 담을 수 있는 트랜잭션 수
+
+var (
+	ErrSignature   = errors.New("transaction has an invalid signature")
+	ErrDuplicateTx = errors.New("transaction is already in the chain")
+	ErrTooManyTxs  = errors.New("block has too many transactions")
+)
+
+// 보낸 사람의 키로 서명한 트랜잭션 - mempool 을 거쳐 블록에 담김
+type Transaction struct {
+	From      string  // 보낸 사람 주소 (PubKey 로부터 만들어짐)
+	PubKey    string  // 보낸 사람의 공개키 (hex)
+	Timestamp int64   // 만든 시간 (Unix 나노초), 같은 내용의 트랜잭션을 구분
+	Payload   Payload // 기록할 데이터
+	Signature string  // 보낸 사람의 개인키로 서명한 값 (hex)
+}
+
+func NewTransaction(w *wallet.Wallet, payload Payload) Transaction { // 트랜잭션 생성 후 서명
+	tx := Transaction{
+		From:      w.Address(),
+		PubKey:    w.PublicKey(),
+		Timestamp: time.Now().UnixNano(),
+		Payload:   payload,
+	}
+	tx.Signature = w.Sign(tx.signingBytes())
+	return tx
+}
+
+func (tx Transaction) encode(e *encoder, withSignature bool) {
+	e.string(tx.From)
+	e.string(tx.PubKey)
+	e.int64(tx.Timestamp)
+	e.string(tx.Payload.Type)
+	e.bytes(tx.Payload.Data)
+	if withSignature {
+		e.string(tx.Signature)
+	}
+}
+
+func (tx *Transaction) decode(d *decoder) {
+	tx.From = d.string()
+	tx.PubKey = d.string()
+	tx.Timestamp = d.int64()
+	tx.Payload.Type = d.string()
+	tx.Payload.Data = d.bytes()
+	tx.Signature = d.string()
+}
+
+func (tx Transaction) signingBytes() []byte { // 서명 대상: 서명을 제외한 정규 인코딩
+	var e encoder
+	tx.encode(&e, false)
+	return e.buf
+}
+
+func (tx Transaction) Hash() string { // 트랜잭션 식별자
+	h := sha256.Sum256(tx.signingBytes())
+	return hex.EncodeToString(h[:])
+}
+
+func (tx Transaction) Verify() error { // 보낸 사람 주소, 서명, 페이로드 검증
+	if err := wallet.VerifyAddress(tx.From, tx.PubKey, tx.signingBytes(), tx.Signature); err != nil {
+		return ErrSignature
+	}
+	return tx.Payload.Validate()
+}
+
+// 블록의 트랜잭션 검증 - seen 은 이전 블록들에 이미 담긴 트랜잭션
+func checkTransactions(block Block, seen map[string]bool) error {
+	if len(block.Transactions) > MaxTransactions {
+		return ErrTooManyTxs
+	}
+
+	inBlock := make(map[string]bool, len(block.Transactions))
+	for _, tx := range block.Transactions {
+		if err := tx.Verify(); err != nil {
+			return err
+		}
+		hash := tx.Hash()
+		if seen[hash] || inBlock[hash] {
+			return ErrDuplicateTx
+		}
+		inBlock[hash] = true
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package chain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+const (
+	MaxUncles     = 2 // 블록 하나에 담을 수 있는 uncle 수
+	MaxUncleDepth = 6 // uncle 은 최근 몇 블록 안의 높이에서 갈라진 블록이어야 하는 지
+)
+
+var ErrUncle = errors.New("block has an invalid uncle")
+
+// 같은 높이에서 경쟁에 진 (체인에 들어가지 못한) 블록 - 트랜잭션 없이 헤더와 페이로드만 담음 (GHOST)
+// 조카 블록이 uncle 을 담으면 uncle 의 작업량도 누적 작업량에 더해지고, uncle 채굴자도 보상 일부를 받음
+type Uncle struct {
+	Header
+	Payload Payload
+	Hash    string
+}
+
+func UncleOf(block Block) Uncle {
+	return Uncle{block.Header, block.Payload, block.Hash}
+}
+
+func (u Uncle) Block() Block { // 해쉬, PoW 검증용 (트랜잭션 없음)
+	return Block{Header: u.Header, Payload: u.Payload, Hash: u.Hash}
+}
+
+func UnclesHash(uncles []Uncle) string { // uncle 해쉬를 이어 붙인 값의 sha256 (uncle 이 없으면 빈 문자열)
+	if len(uncles) == 0 {
+		return ""
+	}
+	h := sha256.New()
+	for _, uncle := range uncles {
+		b, _ := hex.DecodeString(uncle.Hash)
+		h.Write(b)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// uncle 채굴자의 보상: 조카 높이의 보상 * (8 - 거리) / 8
+func (g *GenesisConfig) UncleReward(height int, uncle Uncle) int {
+	return g.Reward(height) * (8 - (height - uncle.Index)) / 8
+}
+
+// uncle 을 담은 조카 블록 채굴자의 추가 보상: uncle 하나마다 보상의 1/32
+func (g *GenesisConfig) NephewReward(height int) int {
+	return g.Reward(height) / 32
+}
+
+// blocks 는 조카 블록의 부모까지의 체인 - uncle 의 부모는 체인에 있고, uncle 자신은 체인에도, 이전 블록의 uncle 에도 없어야 함
+func (c *Chain) CheckUncle(blocks []Block, uncle Uncle) error {
+	height := len(blocks)
+	if uncle.Index < 1 || uncle.Index >= height || height-uncle.Index > MaxUncleDepth {
+		return ErrUncle
+	}
+	if uncle.Version != HeaderVersion || uncle.ChainID != blocks[0].ChainID || CalculateHash(uncle.Block()) != uncle.Hash {
+		return ErrUncle
+	}
+	if blocks[uncle.Index-1].Hash != uncle.PrevHash || blocks[uncle.Index].Hash == uncle.Hash {
+		return ErrUncle
+	}
+	if err := uncle.Payload.Validate(); err != nil {
+		return ErrUncle
+	}
+	if err := c.checkReward(uncle.Block()); err != nil {
+		return ErrUncle
+	}
+	for _, block := range blocks[uncle.Index+1:] {
+		for _, included := range block.Uncles {
+			if included.Hash == uncle.Hash {
+				return ErrUncle
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Chain) checkUncles(blocks []Block, newBlock Block) error {
+	if len(newBlock.Uncles) > MaxUncles {
+		return ErrUncle
+	}
+	for i, uncle := range newBlock.Uncles {
+		if err := c.CheckUncle(blocks, uncle); err != nil {
+			return err
+		}
+		for _, other := range newBlock.Uncles[:i] {
+			if other.Hash == uncle.Hash {
+				return ErrUncle
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package chain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+var (
+	ErrWork    = errors.New("block has an inconsistent total work")
+	ErrNoWork  = errors.New("block claims proof of work on a chain without a work rule")
+	ErrCompact = errors.New("invalid compact target")
+)
+
+// 256비트 목표값의 compact 인코딩 (비트코인 nBits 와 같은 형식)
+// 상위 1바이트는 목표값의 바이트 길이, 하위 3바이트는 앞쪽 유효 숫자: 목표값 = 숫자 * 256^(길이-3)
+// JSON 에서는 "1f0fffff" 처럼 16진수 문자열로 표시
+type Compact uint32
+
+func (c Compact) Target() *big.Int { // 음수 표시 비트가 켜져 있으면 nil
+	size := uint(c >> 24)
+	mantissa := big.NewInt(int64(c & 0x007fffff))
+	if c&0x00800000 != 0 {
+		return nil
+	}
+	if size <= 3 {
+		return mantissa.Rsh(mantissa, 8*(3-size))
+	}
+	return mantissa.Lsh(mantissa, 8*(size-3))
+}
+
+func CompactOf(target *big.Int) Compact { // 목표값을 compact 로 (하위 자리는 버려짐)
+	size := uint((target.BitLen() + 7) / 8)
+	var mantissa uint64
+	if size <= 3 {
+		mantissa = target.Uint64() << (8 * (3 - size))
+	} else {
+		mantissa = new(big.Int).Rsh(target, 8*(size-3)).Uint64()
+	}
+	if mantissa&0x00800000 != 0 { // 최상위 비트는 부호로 쓰이므로 한 바이트 밀어냄
+		mantissa >>= 8
+		size++
+	}
+	return Compact(uint32(size)<<24 | uint32(mantissa))
+}
+
+func (c Compact) String() string {
+	return fmt.Sprintf("%08x", uint32(c))
+}
+
+func (c Compact) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.String())
+}
+
+func (c *Compact) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return ErrCompact
+	}
+	*c = Compact(v)
+	return nil
+}
+
+// 해쉬를 256비트 정수로 보고 목표값 이하인지 비교 (16진수 문자열이 아닌 해쉬 바이트로 비교)
+func MeetsTarget(hash []byte, target *big.Int) bool {
+	if len(hash) != 32 || target == nil || target.Sign() < 0 || target.BitLen() > 256 {
+		return false
+	}
+	return bytes.Compare(hash, target.FillBytes(make([]byte, 32))) <= 0
+}
+
+// 블록 하나를 찾는 데 필요한 평균 해쉬 시도 횟수: 2^256 / (목표값 + 1)
+// 목표값이 없는 블록 (PoW 가 아닌 모드, Bits 0) 은 1
+func Work(bits Compact) *big.Int {
+	target := bits.Target()
+	if target == nil || target.Sign() <= 0 {
+		return big.NewInt(1)
+	}
+	space := new(big.Int).Lsh(big.NewInt(1), 256)
+	return space.Div(space, target.Add(target, big.NewInt(1)))
+}
+
+// 부모 블록까지의 누적 작업량에 새 블록과 새 블록이 담은 uncle 의 작업량을 더한 값 (hex)
+func CumulativeWork(oldBlock, newBlock Block) string {
+	total := oldBlock.totalWork()
+	total.Add(total, Work(newBlock.Bits))
+	for _, uncle := range newBlock.Uncles {
+		total.Add(total, Work(uncle.Bits))
+	}
+	return total.Text(16)
+}
+
+func (b Block) totalWork() *big.Int { // 잘못된 값이면 0
+	total, ok := new(big.Int).SetString(b.TotalWork, 16)
+	if !ok {
+		return new(big.Int)
+	}
+	return total
+}
@@ -0,0 +1,101 @@
+package chain
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+func TestCompactTarget(t *testing.T) {
+	tests := []struct {
+		bits   Compact
+		target string // hex, 비어 있으면 nil (음수 표시)
+	}{
+		{0x1f0fffff, "fffff" + zeros(56)},
+		{0x1d00ffff, "ffff" + zeros(52)},
+		{0x03123456, "123456"},
+		{0x02123400, "1234"},
+		{0x01120000, "12"},
+		{0x04123456, "12345600"},
+		{0x00000000, "0"},
+		{0x04923456, ""},
+	}
+	for _, test := range tests {
+		target := test.bits.Target()
+		if test.target == "" {
+			if target != nil {
+				t.Errorf("%s: got %x, want nil", test.bits, target)
+			}
+			continue
+		}
+		if target == nil || target.Text(16) != test.target {
+			t.Errorf("%s: got %x, want %s", test.bits, target, test.target)
+			continue
+		}
+		if target.Sign() > 0 && CompactOf(target) != test.bits {
+			t.Errorf("%s: CompactOf gives %s", test.bits, CompactOf(target))
+		}
+	}
+}
+
+func TestCompactOf(t *testing.T) {
+	tests := []struct {
+		target string
+		bits   Compact
+	}{
+		{"80", 0x02008000},         // 최상위 비트가 켜지면 한 바이트 밀어냄
+		{"123456789a", 0x05123456}, // 하위 자리는 버려짐
+		{"ffffff", 0x0400ffff},
+		{"7fffff", 0x037fffff},
+		{"1", 0x01010000},
+	}
+	for _, test := range tests {
+		target, _ := new(big.Int).SetString(test.target, 16)
+		bits := CompactOf(target)
+		if bits != test.bits {
+			t.Errorf("%s: got %s, want %s", test.target, bits, test.bits)
+		}
+		if bits.Target().Cmp(target) > 0 {
+			t.Errorf("%s: %s rounds up to %x", test.target, bits, bits.Target())
+		}
+	}
+}
+
+func TestCompactJSON(t *testing.T) {
+	data, _ := json.Marshal(Compact(0x1f0fffff))
+	if string(data) != `"1f0fffff"` {
+		t.Fatalf("got %s", data)
+	}
+	var bits Compact
+	if err := json.Unmarshal(data, &bits); err != nil || bits != 0x1f0fffff {
+		t.Errorf("got %s, %v", bits, err)
+	}
+	if err := json.Unmarshal([]byte(`"xyz"`), &bits); err != ErrCompact {
+		t.Errorf("got %v, want %v", err, ErrCompact)
+	}
+}
+
+func TestWork(t *testing.T) {
+	tests := []struct {
+		bits Compact
+		work int64
+	}{
+		{0, 1},
+		{0x2100ffff, 1},     // 목표값이 2^256 에 가까우면 1
+		{0x1f7fffff, 0x200}, // 목표값 + 1 이 2^247 보다 조금 작음
+		{0x04923456, 1},     // 음수 표시
+	}
+	for _, test := range tests {
+		if work := Work(test.bits); work.Int64() != test.work {
+			t.Errorf("%s: got %s, want %d", test.bits, work, test.work)
+		}
+	}
+}
+
+func zeros(n int) string {
+	s := make([]byte, n)
+	for i := range s {
+		s[i] = '0'
+	}
+	return string(s)
+}
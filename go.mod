@@ -6,6 +6,8 @@ require (
 	github.com/davecgh/go-spew v1.1.1 
 	github.com/gorilla/mux v1.8.0 
 	github.com/joho/godotenv v1.4.0
+	golang.org/x/crypto v0.0.0-20220411220226-7b82a4e95df4
+	lukechampine.com/blake3 v1.1.6
 )
 
 require (
@@ -98,7 +100,6 @@ require (
 	go.uber.org/atomic v1.7.0 // indirect
 	go.uber.org/multierr v1.6.0 // indirect
 	go.uber.org/zap v1.19.1 // indirect
-	golang.org/x/crypto v0.0.0-20220411220226-7b82a4e95df4 // indirect
 	golang.org/x/mod v0.4.2 // indirect
 	golang.org/x/net v0.0.0-20211112202133-69e39bad7dc2 // indirect
 	golang.org/x/sync v0.0.0-20210220032951-036812b2e83c // indirect
@@ -108,5 +109,4 @@ require (
 	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
 	google.golang.org/protobuf v1.26.0 // indirect
 	gopkg.in/tomb.v1 v1.0.0-20141024135613-dd632973f1e7 // indirect
-	lukechampine.com/blake3 v1.1.6 // indirect
 )
@@ -1,14 +1,20 @@
 package main
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
+	"github.com/D0hwQ1/Blockchain-With-Go/chain"
 	P2P "github.com/D0hwQ1/Blockchain-With-Go/p2p"
 	"github.com/D0hwQ1/Blockchain-With-Go/pos"
 	"github.com/D0hwQ1/Blockchain-With-Go/pow"
 	"github.com/D0hwQ1/Blockchain-With-Go/tcp"
+	"github.com/D0hwQ1/Blockchain-With-Go/wallet"
 	"github.com/D0hwQ1/Blockchain-With-Go/web"
 )
 
@@ -23,7 +29,7 @@ func main() {
 			fmt.Println()
 			break
 		} else {
-			fmt.Println("\n잘못된 포트 입력입니다.\n다시 입력해주세요\n")
+			fmt.Print("\n잘못된 포트 입력입니다.\n다시 입력해주세요\n\n")
 			continue
 		}
 	}
@@ -32,7 +38,10 @@ func main() {
 	fmt.Println("tcp : 블록체인 tcp통신을 구동합니다.")
 	fmt.Println("pow : PoW 합의 알고리즘 방식의 블록체인 웹서비스를 구동합니다.")
 	fmt.Println("pos : PoS 합의 알고리즘 방식의 블록체인 웹서비스를 구동합니다.")
-	fmt.Println("p2p : 중앙 노드 기반의 블록체인 웹서비스를 구동합니다.\n\n")
+	fmt.Println("p2p : 중앙 노드 기반의 블록체인 웹서비스를 구동합니다.")
+	fmt.Println("audit : 저장된 블록체인 전체를 검증합니다.")
+	fmt.Println("wallet : 지갑을 만들거나 메시지에 서명합니다.")
+	fmt.Print("tx : 지갑으로 서명된 트랜잭션을 만듭니다. (POST /tx 또는 tcp/pos 에서 'tx {JSON}' 으로 제출)\n\n\n")
 
 	for {
 		var name string
@@ -44,17 +53,17 @@ func main() {
 		switch name {
 		case "web":
 			fmt.Println("링크: http://localhost:" + strconv.Itoa(port))
-			fmt.Println("블록을 생성하실 때에는, 링크에 POST 방식으로 {BPM: value(num)}를 입력하시면 됩니다\n")
+			fmt.Print("블록을 생성하실 때에는, 링크에 POST 방식으로 {BPM: value(num)} 또는 {Type: content-type, Data: value}를 입력하시면 됩니다\n\n")
 			web.Start(strconv.Itoa(port))
 		case "tcp":
-			fmt.Println("접속: nc localhost", port, "\n")
+			fmt.Printf("접속: nc localhost %d\n\n", port)
 			tcp.Start(strconv.Itoa(port))
 		case "pow":
 			fmt.Println("링크: http://localhost:" + strconv.Itoa(port))
-			fmt.Println("블록을 생성하실 때에는, 링크에 POST 방식으로 {BPM: value(num)}를 입력하시면 됩니다\n")
+			fmt.Print("블록을 생성하실 때에는, 링크에 POST 방식으로 {BPM: value(num)} 또는 {Type: content-type, Data: value}를 입력하시면 됩니다\n\n")
 			pow.Start(strconv.Itoa(port))
 		case "pos":
-			fmt.Println("접속: nc localhost", port, "\n")
+			fmt.Printf("접속: nc localhost %d (검증자 프로그램은 pos/client 패키지로 JSON 프로토콜 사용)\n\n", port)
 			pos.Start(strconv.Itoa(port))
 		case "p2p":
 			var yn string
@@ -79,9 +88,135 @@ func main() {
 					P2P.Start(port, false, "")
 				}
 			}
+		case "audit":
+			audit(port)
+		case "wallet":
+			walletCommand()
+		case "tx":
+			transaction()
 
 		default:
 			fmt.Printf("'%s'은 잘못된 입력입니다.\n\n\n", name)
 		}
 	}
 }
+
+func audit(port int) { // 모드를 입력받아 저장된 체인 전체를 검증하고 결과 출력
+	var name string
+
+	fmt.Print("검증할 모드 입력(web/tcp/pow/pos/p2p): ")
+	fmt.Scanf("%s", &name)
+	fmt.Println()
+
+	var report chain.AuditReport
+	var err error
+
+	switch name {
+	case "web":
+		report, err = web.Audit()
+	case "tcp":
+		report, err = tcp.Audit()
+	case "pow":
+		report, err = pow.Audit()
+	case "pos":
+		report, err = pos.Audit()
+	case "p2p":
+		report, err = P2P.Audit(port)
+	default:
+		fmt.Printf("'%s'은 잘못된 입력입니다.\n\n\n", name)
+		return
+	}
+	if err != nil {
+		fmt.Printf("검증 실패: %v\n\n", err)
+		return
+	}
+
+	bytes, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fmt.Printf("검증 실패: %v\n\n", err)
+		return
+	}
+	fmt.Printf("%s\n\n", bytes)
+}
+
+func loadWallet(reader *bufio.Reader) (*wallet.Wallet, bool) { // 지갑 파일 경로를 입력받아 지갑을 불러옴
+	fmt.Print("지갑 파일 경로 입력: ")
+	path, _ := reader.ReadString('\n')
+
+	w, err := wallet.Load(strings.TrimSpace(path))
+	if err != nil {
+		fmt.Printf("지갑을 불러올 수 없습니다: %v\n\n", err)
+		return nil, false
+	}
+	return w, true
+}
+
+func walletCommand() { // 지갑 생성 / 메시지 서명 (pos 검증자 등록 challenge, 블록 해쉬 서명에 사용)
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Print("new : 새 지갑 생성 | sign : 메시지 서명\n작업 입력: ")
+	name, _ := reader.ReadString('\n')
+
+	switch strings.TrimSpace(name) {
+	case "new":
+		w, err := wallet.Generate()
+		if err != nil {
+			fmt.Printf("지갑 생성 실패: %v\n\n", err)
+			return
+		}
+		path := filepath.Join(wallet.Dir(), w.Address()+".json")
+		if err := w.Save(path); err != nil {
+			fmt.Printf("지갑 저장 실패: %v\n\n", err)
+			return
+		}
+		fmt.Printf("주소: %s\n공개키: %s\n지갑 파일: %s\n\n", w.Address(), w.PublicKey(), path)
+	case "sign":
+		w, ok := loadWallet(reader)
+		if !ok {
+			return
+		}
+		fmt.Print("서명할 메시지 입력: ")
+		msg, _ := reader.ReadString('\n')
+		fmt.Printf("서명: %s\n\n", w.Sign([]byte(strings.TrimSpace(msg))))
+	default:
+		fmt.Print("잘못된 입력입니다.\n\n")
+	}
+}
+
+func transaction() { // 지갑과 페이로드를 입력받아 서명된 트랜잭션 JSON 출력
+	reader := bufio.NewReader(os.Stdin)
+
+	w, ok := loadWallet(reader)
+	if !ok {
+		return
+	}
+
+	fmt.Print("페이로드 입력(BPM 정수, JSON, 텍스트, 'bond 수량', 'unbond 수량' 또는 'slash 증거JSON'): ")
+	line, _ := reader.ReadString('\n')
+
+	payload := chain.ParsePayload(line)
+	if fields := strings.Fields(line); len(fields) == 2 && (fields[0] == chain.StakeBond || fields[0] == chain.StakeUnbond) {
+		amount, err := strconv.Atoi(fields[1])
+		if err != nil || amount <= 0 {
+			fmt.Print("잘못된 수량입니다.\n\n")
+			return
+		}
+		payload = chain.StakePayload(fields[0], amount)
+	}
+	if data := strings.TrimSpace(line); strings.HasPrefix(data, chain.TypeSlash+" ") { // pos 노드가 알린 이중 제안 증거
+		var evidence chain.Evidence
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(data, chain.TypeSlash+" ")), &evidence); err != nil || evidence.Verify() != nil {
+			fmt.Print("잘못된 증거입니다.\n\n")
+			return
+		}
+		payload = chain.SlashPayload(evidence)
+	}
+
+	tx := chain.NewTransaction(w, payload)
+	bytes, err := json.Marshal(tx)
+	if err != nil {
+		fmt.Printf("트랜잭션 생성 실패: %v\n\n", err)
+		return
+	}
+	fmt.Printf("%s\n\n", bytes)
+}
@@ -0,0 +1,100 @@
+package mempool
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/D0hwQ1/Blockchain-With-Go/chain"
+)
+
+const maxPending = 10000 // mempool 에 쌓아 둘 수 있는 트랜잭션 수
+
+var (
+	ErrDuplicate    = errors.New("transaction is already pending")
+	ErrFull         = errors.New("mempool is full")
+	ErrInapplicable = errors.New("transaction cannot apply to the chain state")
+)
+
+// 검증된 트랜잭션을 블록에 담기 전까지 들어온 순서대로 보관
+type Mempool struct {
+	mutex   sync.Mutex
+	chain   *chain.Chain
+	pending []chain.Transaction
+	hashes  map[string]bool
+}
+
+func New(c *chain.Chain) *Mempool {
+	return &Mempool{chain: c, hashes: make(map[string]bool)}
+}
+
+// 서명 검증 후 대기열에 추가 - 체인 상태에 대기 중인 트랜잭션을 먼저 적용해도 적용할 수 없는 트랜잭션 (잔액보다 큰 bond 등) 은 받지 않음
+func (m *Mempool) Add(tx chain.Transaction) error {
+	if err := tx.Verify(); err != nil {
+		return err
+	}
+
+	hash := tx.Hash()
+	if m.chain.HasTransaction(hash) {
+		return chain.ErrDuplicateTx
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.hashes[hash] {
+		return ErrDuplicate
+	}
+	if len(m.pending) >= maxPending {
+		return ErrFull
+	}
+	applicable := m.chain.Applicable(append(m.pending[:len(m.pending):len(m.pending)], tx))
+	if len(applicable) == 0 || applicable[len(applicable)-1].Hash() != hash {
+		return ErrInapplicable
+	}
+	m.pending = append(m.pending, tx)
+	m.hashes[hash] = true
+	return nil
+}
+
+// 블록 생성자가 새 블록에 담을 트랜잭션을 먼저 들어온 순서로 최대 max 개 가져감 (대기열에서 지우지는 않음)
+// 지금 체인 상태로는 적용할 수 없는 stake 트랜잭션은 건너뜀
+func (m *Mempool) Batch(max int) []chain.Transaction {
+	batch := m.chain.Applicable(m.Pending())
+	if max > len(batch) {
+		max = len(batch)
+	}
+	return batch[:max]
+}
+
+// 블록이 체인에 추가된 뒤, 그 블록에 담긴 트랜잭션과 새 상태에서 더 이상 적용할 수 없게 된 트랜잭션을 대기열에서 지움
+func (m *Mempool) Remove(txs []chain.Transaction) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	removed := make(map[string]bool, len(txs))
+	for _, tx := range txs {
+		removed[tx.Hash()] = true
+	}
+
+	pending := m.pending[:0]
+	for _, tx := range m.pending {
+		if hash := tx.Hash(); !removed[hash] && !m.chain.HasTransaction(hash) { // 다른 노드의 블록으로 이미 담긴 것도 지움
+			pending = append(pending, tx)
+		}
+	}
+	m.pending = m.chain.Applicable(pending)
+
+	m.hashes = make(map[string]bool, len(m.pending))
+	for _, tx := range m.pending {
+		m.hashes[tx.Hash()] = true
+	}
+}
+
+func (m *Mempool) Pending() []chain.Transaction { // 대기 중인 트랜잭션 목록
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	pending := make([]chain.Transaction, len(m.pending))
+	copy(pending, m.pending)
+	return pending
+}
@@ -2,24 +2,24 @@ package P2P
 
 import (
 	"bufio"
-	"bytes"
 	"context"
 	"crypto/rand"
-	"crypto/sha256"
-	"encoding/hex"
+	"encoding/base64"
 	"encoding/json"
-	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log"
 	mrand "math/rand"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/D0hwQ1/Blockchain-With-Go/chain"
+	"github.com/D0hwQ1/Blockchain-With-Go/storage"
 	"github.com/davecgh/go-spew/spew"
 	"github.com/libp2p/go-libp2p"
 	"github.com/libp2p/go-libp2p-core/crypto"
@@ -30,24 +30,20 @@ import (
 	ma "github.com/multiformats/go-multiaddr"
 )
 
-type Block struct {
-	Index     int
-	Timestamp string
-	BPM       int
-	Hash      string
-	PrevHash  string
-}
-
-var Blockchain []Block
+var Blockchain *chain.Chain
 
 var mutex = &sync.Mutex{}
 
 func Start(port int, secio bool, target string /* 노드(호스트)에 접속하기 위한 피어 입력칸 */) {
-	t := time.Now()
-	genesisBlock := Block{}
-	genesisBlock = Block{0, t.String(), 0, calculateHash(genesisBlock), ""}
+	genesis, err := chain.LoadGenesis() // 모든 노드가 같은 genesis 파일을 사용해야 체인을 주고받을 수 있음
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	Blockchain = append(Blockchain, genesisBlock)
+	Blockchain, err = storage.OpenChain(filepath.Join("p2p", strconv.Itoa(port)), genesis) // 노드(포트)별로 체인 저장
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	seed := flag.Int64("seed", 0, "set random seed for id generation")
 	flag.Parse() // flag 받은 값 세팅
@@ -126,21 +122,21 @@ func readData(rw *bufio.ReadWriter) { // 다른 노드로부터 값(블록체인
 			return
 		}
 		if str != "\n" {
-			chain := make([]Block, 0)
-			if err := json.Unmarshal([]byte(str), &chain); err != nil {
-				log.Fatal(err)
+			blocks, err := decodeChain(str)
+			if err != nil {
+				log.Println(err)
+				continue
 			}
 
 			mutex.Lock()
-			if len(chain) > len(Blockchain) { // 들어오는 체인이 기존 블록보다 길면 최신 네트워크 상태로 변경
-				Blockchain = chain
-				bytes, err := json.MarshalIndent(Blockchain, "", "  ")
+			if Blockchain.Replace(blocks) { // 들어오는 체인의 누적 작업량이 더 많고 유효하면 최신 네트워크 상태로 변경
+				bytes, err := json.MarshalIndent(Blockchain.Blocks(), "", "  ")
 				if err != nil {
-					log.Fatal(err)
+					log.Println(err)
 				}
 				fmt.Printf("\n\x1b[32m%s\x1b[0m\n> ", string(bytes)) // 호스트 콘솔에 색상으로 블록체인 출력
 			} else {
-				fmt.Print("\nApplying Blockchain Length...\n> ")
+				fmt.Print("\nKeeping the local chain...\n> ")
 			}
 			mutex.Unlock()
 		}
@@ -148,23 +144,20 @@ func readData(rw *bufio.ReadWriter) { // 다른 노드로부터 값(블록체인
 }
 
 func writeData(rw *bufio.ReadWriter) { // 다른 노드에 값(블록체인)을 전송하는 함수
-	prev, _ := json.Marshal(Blockchain)
+	prev := encodeChain(Blockchain.Blocks())
 
 	go func() {
 		for {
 			time.Sleep(30 * time.Second)
 
 			mutex.Lock()
-			curr, err := json.Marshal(Blockchain)
-			if err != nil {
-				log.Print(err)
-			}
+			curr := encodeChain(Blockchain.Blocks())
 			mutex.Unlock()
 
 			mutex.Lock()
 			// 기존 블록체인과 30초 이후 조회한 블록체인이 다를 경우(추가되었을 경우가 해당)
-			if !bytes.Equal(prev, curr) {
-				rw.WriteString(fmt.Sprintf("%s\n", string(curr)))
+			if prev != curr {
+				rw.WriteString(fmt.Sprintf("%s\n", curr))
 				rw.Flush() // 연결된 모든 노드에 블록체인 전송
 				prev = curr
 			}
@@ -182,37 +175,49 @@ func writeData(rw *bufio.ReadWriter) { // 다른 노드에 값(블록체인)을
 		}
 
 		sendData = strings.Replace(sendData, "\n", "", -1) // 개행 제거
-		bpm, err := strconv.Atoi(sendData)
-		if err != nil {
-			log.Fatal(err)
+
+		if sendData == "audit" { // 전체 체인 검증 결과 출력
+			spew.Dump(Blockchain.Audit())
+			continue
 		}
-		newBlock, err := generateBlock(Blockchain[len(Blockchain)-1], bpm) // 블록 생성
-		if err != nil {
+		payload := chain.ParsePayload(sendData) // 정수면 BPM, JSON 이면 JSON, 나머지는 바이트
+		if err := Blockchain.Validate(); err != nil {
 			log.Fatal(err)
 			break
 		}
+		newBlock := chain.GenerateBlock(Blockchain.Tip(), payload) // 블록 생성
 
-		if isBlockValid(newBlock, Blockchain[len(Blockchain)-1]) { // 블록이 유효한지 확인
-			mutex.Lock()
-			Blockchain = append(Blockchain, newBlock)
-			mutex.Unlock()
-		}
-
-		bytes, err := json.Marshal(Blockchain)
-		if err != nil {
+		mutex.Lock()
+		if err := Blockchain.Append(newBlock); err != nil { // 블록이 유효한지 확인 후 추가
 			log.Println(err)
 		}
+		mutex.Unlock()
 
-		spew.Dump(Blockchain)
+		blocks := Blockchain.Blocks()
+
+		spew.Dump(blocks)
 
 		mutex.Lock()
-		rw.WriteString(fmt.Sprintf("%s\n", bytes)) // 개행으로 인하여 생성된 블록이 readWrite 함수로 이동
-		rw.Flush()                                 // 연결된 모든 노드에 블록체인 정보 전송
+		rw.WriteString(fmt.Sprintf("%s\n", encodeChain(blocks))) // 개행으로 인하여 생성된 블록이 readWrite 함수로 이동
+		rw.Flush()                                               // 연결된 모든 노드에 블록체인 정보 전송
 		mutex.Unlock()
 	}
 
 }
 
+// 블록체인을 정규 바이너리 인코딩 후 base64 로 바꿔 한 줄로 전송
+func encodeChain(blocks []chain.Block) string {
+	return base64.StdEncoding.EncodeToString(chain.EncodeBlocks(blocks))
+}
+
+func decodeChain(str string) ([]chain.Block, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(str))
+	if err != nil {
+		return nil, err
+	}
+	return chain.DecodeBlocks(data)
+}
+
 // 임의의 피어로 블록체인을 송수신할 p2p 노드(호스트) 생성
 func makeBasicHost(listenPort int, secio bool, randseed int64) (host.Host, error) {
 	var r io.Reader
@@ -251,67 +256,10 @@ func makeBasicHost(listenPort int, secio bool, randseed int64) (host.Host, error
 	return basicHost, nil // p2p 인스턴스 반환
 }
 
-func calculateHash(block Block) string { // 해쉬 생성
-	record := strconv.Itoa(block.Index) + block.Timestamp + strconv.Itoa(block.BPM) + block.PrevHash
-	h := sha256.New()
-	h.Write([]byte(record))
-	hashed := h.Sum(nil)
-	return hex.EncodeToString(hashed)
-}
-
-func isBlockValid(newBlock, oldBlock Block) bool { // 추가할 블록 변조 체크
-	if oldBlock.Index+1 != newBlock.Index {
-		return false
-	}
-	if oldBlock.Hash != newBlock.PrevHash {
-		return false
-	}
-	if calculateHash(newBlock) != newBlock.Hash {
-		return false
-	}
-
-	return true
-}
-func isBlockchainValid() error { // 전체 체인 변조 체크
-	currBlockIdx := len(Blockchain) - 1
-	prevBlockIdx := len(Blockchain) - 2
-
-	for prevBlockIdx >= 0 {
-		currBlock := Blockchain[currBlockIdx]
-		prevBlock := Blockchain[prevBlockIdx]
-
-		if currBlock.PrevHash != prevBlock.Hash {
-			return errors.New("blockchain has inconsistent hashes")
-		}
-
-		if currBlock.Timestamp <= prevBlock.Timestamp {
-			return errors.New("blockchain has inconsistent timestamps")
-		}
-
-		if calculateHash(currBlock) != currBlock.Hash {
-			return errors.New("blockchain has inconsistent hash generation")
-		}
-
-		currBlockIdx--
-		prevBlockIdx--
-	}
-	return nil
-}
-
-func generateBlock(oldBlock Block, BPM int) (Block, error) { // BPM을 입력받아 블록 생성
-	if err := isBlockchainValid(); err != nil {
-		return oldBlock, err
+func Audit(port int) (chain.AuditReport, error) { // 저장된 체인 전체 검증 (CLI audit 명령)
+	genesis, err := chain.LoadGenesis()
+	if err != nil {
+		return chain.AuditReport{}, err
 	}
-
-	var newBlock Block
-
-	t := time.Now()
-
-	newBlock.Index = oldBlock.Index + 1
-	newBlock.Timestamp = t.String()
-	newBlock.BPM = BPM
-	newBlock.PrevHash = oldBlock.Hash
-	newBlock.Hash = calculateHash(newBlock)
-
-	return newBlock, nil
+	return storage.AuditChain(filepath.Join("p2p", strconv.Itoa(port)), genesis)
 }
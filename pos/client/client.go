@@ -0,0 +1,249 @@
+// pos 노드의 검증자 프로토콜 (한 줄에 JSON 하나) 을 사용하는 클라이언트
+//
+//	c, err := client.Dial("localhost:9000")
+//	addr, err := c.Register(w)                      // 지갑으로 challenge 에 서명해서 검증자 등록
+//	hash, err := c.Stake(w, chain.StakeBond, 10)    // 서명된 stake 트랜잭션 제출
+//	block, err := c.Propose(w, chain.BPMPayload(72)) // 블록을 만들어 받고, 해쉬에 서명해서 후보로 제출
+//	events, err := c.Subscribe()                    // 추가된 블록, 놓친 slot, 이중 제안 알림
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/D0hwQ1/Blockchain-With-Go/chain"
+	"github.com/D0hwQ1/Blockchain-With-Go/pos"
+	"github.com/D0hwQ1/Blockchain-With-Go/wallet"
+)
+
+var (
+	ErrClosed     = errors.New("client: connection closed")
+	ErrProposal   = errors.New("client: proposed block does not match the request or the chain tip")
+	ErrDoubleSign = errors.New("client: already signed a different block at this height and slot")
+)
+
+type signedKey struct {
+	Height int
+	Slot   int
+}
+
+type Client struct {
+	conn  net.Conn
+	Hello pos.Hello // 접속할 때 받은 노드 정보
+
+	mutex   sync.Mutex
+	nextID  int
+	pending map[int]chan pos.Response // 응답을 기다리는 요청
+	events  chan pos.Event
+	err     error                // 연결이 끊긴 이유
+	signed  map[signedKey]string // 높이와 slot 별로 서명한 블록 해쉬 (같은 slot 에 다른 블록에 서명하면 slash 됨)
+}
+
+// 노드에 접속해서 바로 hello 를 보냄 (접속 후 처음 보내는 줄이 JSON 이어야 대화형 모드가 아닌 이 프로토콜로 응답함)
+func Dial(address string) (*Client, error) {
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(conn)
+}
+
+func newClient(conn net.Conn) (*Client, error) {
+	c := &Client{conn: conn, pending: make(map[int]chan pos.Response), signed: make(map[signedKey]string)}
+	go c.read()
+
+	if err := c.call("hello", nil, &c.Hello); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// 응답은 요청 ID 로 기다리는 쪽에, 알림은 구독 채널로 보냄
+func (c *Client) read() {
+	scanner := bufio.NewScanner(c.conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*chain.MaxPayloadSize)
+	for scanner.Scan() {
+		var res pos.Response
+		if json.Unmarshal(scanner.Bytes(), &res) != nil {
+			continue
+		}
+
+		c.mutex.Lock()
+		if res.Event != nil {
+			if c.events != nil {
+				select {
+				case c.events <- *res.Event:
+				default: // 읽지 않는 구독자 때문에 응답이 막히지 않도록 버림
+				}
+			}
+		} else if ch, ok := c.pending[res.ID]; ok {
+			delete(c.pending, res.ID)
+			ch <- res
+		}
+		c.mutex.Unlock()
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.err = scanner.Err()
+	if c.err == nil {
+		c.err = ErrClosed
+	}
+	for id, ch := range c.pending {
+		delete(c.pending, id)
+		close(ch)
+	}
+	if c.events != nil {
+		close(c.events)
+	}
+}
+
+// 요청을 보내고 응답의 Result 를 result 에 담음
+func (c *Client) call(method string, params interface{}, result interface{}) error {
+	req := pos.Request{Method: method}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		req.Params = data
+	}
+
+	ch := make(chan pos.Response, 1)
+	c.mutex.Lock()
+	if c.err != nil {
+		c.mutex.Unlock()
+		return c.err
+	}
+	c.nextID++
+	req.ID = c.nextID
+	c.pending[req.ID] = ch
+	data, err := json.Marshal(req)
+	if err == nil {
+		_, err = c.conn.Write(append(data, '\n'))
+	}
+	if err != nil {
+		delete(c.pending, req.ID)
+		c.mutex.Unlock()
+		return err
+	}
+	c.mutex.Unlock()
+
+	res, ok := <-ch
+	if !ok {
+		return ErrClosed
+	}
+	if res.Error != "" {
+		return errors.New(res.Error)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(res.Result, result)
+}
+
+func (c *Client) Register(w *wallet.Wallet) (string, error) { // challenge 에 서명해서 검증자로 등록하고 주소를 받음
+	var challenge pos.Challenge
+	if err := c.call("challenge", nil, &challenge); err != nil {
+		return "", err
+	}
+	var registered pos.Registered
+	err := c.call("register", pos.RegisterParams{PubKey: w.PublicKey(), Signature: w.Sign([]byte(challenge.Challenge))}, &registered)
+	return registered.Address, err
+}
+
+func (c *Client) Status(address string) (pos.Status, error) { // address 가 비어 있으면 등록한 주소
+	var status pos.Status
+	err := c.call("status", pos.StatusParams{Address: address}, &status)
+	return status, err
+}
+
+func (c *Client) Slots() (pos.Schedule, error) { // 지금 slot, epoch 과 다가오는 제안자
+	var schedule pos.Schedule
+	err := c.call("slots", nil, &schedule)
+	return schedule, err
+}
+
+func (c *Client) SubmitTx(tx chain.Transaction) (string, error) { // 서명된 트랜잭션을 mempool 에 제출하고 해쉬를 받음
+	var submitted pos.Submitted
+	err := c.call("tx", tx, &submitted)
+	return submitted.Hash, err
+}
+
+func (c *Client) Stake(w *wallet.Wallet, action string, amount int) (string, error) { // bond / unbond 트랜잭션에 서명해서 제출
+	var submitted pos.Submitted
+	err := c.call("stake", chain.NewTransaction(w, chain.StakePayload(action, amount)), &submitted)
+	return submitted.Hash, err
+}
+
+// 지금 slot 의 제안자이면 노드가 만든 블록을 받아 해쉬에 서명하고 후보로 제출
+// 지갑으로 앞서 약속한 비밀값을 공개하고 다음 비밀값을 약속함 (같은 slot 에 다시 제안하면 노드는 처음 만든 블록을 돌려줌)
+// 노드를 믿지 않고, 요청과 tip 에 맞지 않는 블록이나 같은 높이와 slot 에 이미 서명한 것과 다른 블록에는 서명하지 않음
+func (c *Client) Propose(w *wallet.Wallet, payload chain.Payload) (chain.Block, error) {
+	var block chain.Block
+	var hello pos.Hello // 지금 tip
+	if err := c.call("hello", nil, &hello); err != nil {
+		return block, err
+	}
+	status, err := c.Status(w.Address())
+	if err != nil {
+		return block, err
+	}
+	reveal, commit := chain.RevealPair(w, c.Hello.ChainID, status.Round)
+	if err := c.call("propose", pos.ProposeParams{Payload: payload, Reveal: reveal, Commit: commit}, &block); err != nil {
+		return block, err
+	}
+	if chain.CalculateHash(block) != block.Hash { // 서명하기 전에 노드가 보낸 해쉬가 블록과 맞는지 확인
+		return block, chain.ErrHash
+	}
+	if block.Validator != w.Address() || block.ChainID != c.Hello.ChainID || block.Index != hello.Height || block.PrevHash != hello.Tip ||
+		block.Reveal != reveal || block.Commit != commit {
+		return block, ErrProposal
+	}
+	if err := c.remember(block); err != nil {
+		return block, err
+	}
+	block.Sign(w)
+	return block, c.call("submit", pos.SubmitParams{Hash: block.Hash, Signature: block.Signature}, nil)
+}
+
+func (c *Client) remember(block chain.Block) error { // 서명할 블록을 기록, 같은 높이와 slot 에 다른 블록이 있으면 거부
+	key := signedKey{block.Index, c.Hello.Genesis.Slot(block.Timestamp)}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if hash, ok := c.signed[key]; ok && hash != block.Hash {
+		return ErrDoubleSign
+	}
+	c.signed[key] = block.Hash
+	return nil
+}
+
+func (c *Client) SubmitBlock(block chain.Block) (string, error) { // 직접 만들고 서명한 블록을 후보로 제출
+	var submitted pos.Submitted
+	err := c.call("submit", pos.SubmitParams{Block: &block}, &submitted)
+	return submitted.Hash, err
+}
+
+// 알림 채널 - 연결이 끊기면 닫힘
+func (c *Client) Subscribe() (<-chan pos.Event, error) {
+	c.mutex.Lock()
+	if c.err != nil {
+		c.mutex.Unlock()
+		return nil, c.err
+	}
+	if c.events == nil {
+		c.events = make(chan pos.Event, 64)
+	}
+	events := c.events
+	c.mutex.Unlock()
+
+	return events, c.call("subscribe", nil, nil)
+}
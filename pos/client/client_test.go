@@ -0,0 +1,107 @@
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/D0hwQ1/Blockchain-With-Go/chain"
+	"github.com/D0hwQ1/Blockchain-With-Go/pos"
+	"github.com/D0hwQ1/Blockchain-With-Go/wallet"
+)
+
+// 요청마다 build 로 만든 블록을 돌려주는 노드
+func fakeNode(conn net.Conn, genesis *chain.GenesisConfig, build func(pos.ProposeParams) chain.Block) {
+	tip := genesis.Block()
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var req pos.Request
+		if json.Unmarshal(scanner.Bytes(), &req) != nil {
+			return
+		}
+		var result interface{}
+		switch req.Method {
+		case "hello":
+			result = pos.Hello{Version: pos.ProtocolVersion, ChainID: genesis.ChainID, Height: 1, Tip: tip.Hash, Genesis: *genesis}
+		case "status":
+			result = pos.Status{}
+		case "propose":
+			var params pos.ProposeParams
+			json.Unmarshal(req.Params, &params)
+			result = build(params)
+		case "submit":
+			result = pos.Submitted{}
+		}
+		data, _ := json.Marshal(result)
+		res, _ := json.Marshal(pos.Response{ID: req.ID, Result: data})
+		if _, err := conn.Write(append(res, '\n')); err != nil {
+			return
+		}
+	}
+}
+
+func TestProposeGuards(t *testing.T) {
+	w, err := wallet.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := wallet.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesis := chain.DefaultGenesis
+	slotTime := func(slot int) int64 { return genesis.SlotTime(slot).UnixNano() }
+
+	var timestamp int64
+	var tamper func(*chain.Block)
+	build := func(params pos.ProposeParams) chain.Block {
+		block := chain.NewBlock(genesis.Block(), params.Payload)
+		block.Validator = w.Address()
+		block.Reveal, block.Commit = params.Reveal, params.Commit
+		block.Timestamp = timestamp
+		if tamper != nil {
+			tamper(&block)
+		}
+		block.Hash = chain.CalculateHash(block)
+		return block
+	}
+
+	conn, node := net.Pipe()
+	go fakeNode(node, &genesis, build)
+	c, err := newClient(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	tests := []struct {
+		name   string
+		bpm    int
+		slot   int
+		offset time.Duration // slot 시작부터
+		tamper func(*chain.Block)
+		err    error
+	}{
+		{"other validator", 1, 5, 0, func(b *chain.Block) { b.Validator = other.Address() }, ErrProposal},
+		{"not on the tip", 1, 5, 0, func(b *chain.Block) { b.PrevHash = "00" }, ErrProposal},
+		{"wrong height", 1, 5, 0, func(b *chain.Block) { b.Index = 2 }, ErrProposal},
+		{"other reveal", 1, 5, 0, func(b *chain.Block) { b.Reveal = "00" }, ErrProposal},
+		{"other commit", 1, 5, 0, func(b *chain.Block) { b.Commit = "00" }, ErrProposal},
+		{"first block", 1, 5, 0, nil, nil},
+		{"same block again", 1, 5, 0, nil, nil},
+		{"other block in the same slot", 2, 5, time.Second, nil, ErrDoubleSign},
+		{"same height in a later slot", 2, 6, 0, nil, nil},
+	}
+	for _, test := range tests {
+		timestamp, tamper = slotTime(test.slot)+int64(test.offset), test.tamper
+		block, err := c.Propose(w, chain.BPMPayload(test.bpm))
+		if err != test.err {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.err)
+		}
+		if signed := block.Signature != ""; signed != (test.err == nil) {
+			t.Errorf("%s: signed %v, want %v", test.name, signed, test.err == nil)
+		}
+	}
+}
@@ -3,47 +3,51 @@ package pos
 import (
 	"bufio"
 	"bytes"
-	"crypto/sha256"
+	crand "crypto/rand"
 	"encoding/hex"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"log"
-	"math/rand"
 	"net"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/D0hwQ1/Blockchain-With-Go/api"
+	"github.com/D0hwQ1/Blockchain-With-Go/chain"
+	"github.com/D0hwQ1/Blockchain-With-Go/mempool"
+	"github.com/D0hwQ1/Blockchain-With-Go/storage"
+	"github.com/D0hwQ1/Blockchain-With-Go/wallet"
 	"github.com/davecgh/go-spew/spew"
 )
 
-type Block struct {
-	Index     int    // 데이터 레코드 위치
-	Timestamp string // 데이터 기록되는 시간
-	BPM       int    // business process management
-	Hash      string // 해당 블록 sha256 해쉬값
-	PrevHash  string // 이전 블록의 sha256 해쉬값
-	Validator string // staking 한 토큰의 수량
-}
-
-var Blockchain []Block // 체인 선언
-var tempBlocks []Block // Blockchain에 추가 될 블록을 경쟁하여 정해지기 전까지 담아두는 임시 변수
+var Blockchain *chain.Chain  // 체인 선언
+var tempBlocks []chain.Block // Blockchain에 추가 될 블록을 경쟁하여 정해지기 전까지 담아두는 임시 변수
 
-var candidateBlocks = make(chan Block) // 각 노드(클라이언트)가 제안하는 새 블록이 담기는 곳
-var announcements = make(chan string)  // 최신 블록을 접속한 모든 클라이언트에게 브로드 캐스트 전송
-var validators = make(map[string]int)  // 노드(클라이언트)의 맵과 staking한 수량
+var candidateBlocks = make(chan chain.Block) // 각 노드(클라이언트)가 제안하는 새 블록이 담기는 곳
+var txPool *mempool.Mempool                  // 블록에 담길 트랜잭션 대기열
+var proposals = make(map[string]chain.Block) // 검증자와 높이 별로 처음 받은 후보 블록 (이중 제안 감지)
+var built = make(map[string]chain.Block)     // 검증자와 slot 별로 서명하라고 만들어 준 블록 (같은 slot 에 다른 블록을 서명하게 하지 않음)
+var genesisConfig *chain.GenesisConfig
 
 var mutex = &sync.Mutex{}
 
 func Start(port string) {
 
-	t := time.Now()
-	genesisBlock := Block{}
-	genesisBlock = Block{0, t.String(), 0, calculateHash(genesisBlock), "", ""} // 첫 블록 생성
-	Blockchain = append(Blockchain, genesisBlock)
-	spew.Dump(genesisBlock)
+	genesis, err := chain.LoadGenesis()
+	if err != nil {
+		log.Fatal(err)
+	}
+	genesisConfig = genesis
+
+	Blockchain, err = storage.OpenChain("pos", genesis, validatorRule) // 저장된 체인을 불러오거나 첫 블록 생성
+	if err != nil {
+		log.Fatal(err)
+	}
+	spew.Dump(Blockchain.Blocks())
+	txPool = mempool.New(Blockchain)
 
 	server, err := net.Listen("tcp", ":"+port) // tcp 통신 서버 오픈
 	if err != nil {
@@ -53,9 +57,7 @@ func Start(port string) {
 
 	go func() { // 노드가 블록을 생성할 경우, tempBlocks에 담음
 		for candidate := range candidateBlocks {
-			mutex.Lock()
-			tempBlocks = append(tempBlocks, candidate)
-			mutex.Unlock()
+			receive(candidate)
 		}
 	}()
 
@@ -77,209 +79,341 @@ func Start(port string) {
 func handleConn(conn net.Conn) { // tcp에 통신한 클라이언트의 블록 생성
 	defer conn.Close()
 
+	reader := bufio.NewReader(conn)
+	if isProtocolClient(conn, reader) { // 검증자 프로그램은 한 줄에 JSON 하나씩 주고받음 (protocol.go)
+		serveProtocol(conn, reader)
+		return
+	}
+
+	events := subscribe() // 추가된 블록, 놓친 slot, 이중 제안을 접속한 모든 클라이언트에게 알림
+	defer unsubscribe(events)
 	go func() {
-		for {
-			msg := <-announcements
-			io.WriteString(conn, msg)
+		for event := range events {
+			io.WriteString(conn, event.String())
 		}
 	}()
 
-	var addr string
-
+	done := make(chan struct{})
 	go func() {
-		addr = randAddress()
-		io.WriteString(conn, "현재 블록\n"+spew.Sdump(Blockchain))
-		io.WriteString(conn, "\nYou are Address: "+addr)
-		io.WriteString(conn, "\nEnter token balance: ")
-
-		for {
-			scanBalance := bufio.NewScanner(conn)
-			scanBalance.Scan()
+		defer close(done)
 
-			balance, err := strconv.Atoi(scanBalance.Text())
-			if err != nil {
-				io.WriteString(conn, fmt.Sprintf("%v not a number: %s\nEnter token balance: ", scanBalance.Text(), err))
-				continue
-			}
+		scanner := bufio.NewScanner(reader)
+		scanner.Buffer(make([]byte, 0, 64*1024), 4*chain.MaxPayloadSize) // slash 트랜잭션은 블록 두 개의 페이로드를 담음
 
-			validators[addr] = balance
-			fmt.Println(validators)
+		io.WriteString(conn, "현재 블록\n"+spew.Sdump(Blockchain.Blocks()))
 
-			io.WriteString(conn, "Enter a new BPM: ")
+		addr, pubKey, ok := register(conn, scanner) // 지갑 공개키를 받고 서명으로 키의 주인인지 확인
+		if !ok {
+			return
+		}
+		io.WriteString(conn, "\nYou are Address: "+addr)
 
-			scanBPM := bufio.NewScanner(conn)
-			scanBPM.Scan()
+		// stake 는 직접 입력하지 않고, 서명된 bond 트랜잭션 (tx 명령의 "bond 수량") 으로 체인에 묶음
+		io.WriteString(conn, stakeStatus(addr)+"\nEnter a new BPM (or JSON/text payload): ")
 
-			bpm, err := strconv.Atoi(scanBPM.Text())
-			if err != nil {
-				io.WriteString(conn, fmt.Sprintf("%v not a number: %s\nEnter a new BPM: ", scanBPM.Text(), err))
-				delete(validators, addr)
-				conn.Close()
+		for scanner.Scan() {
+			if line := scanner.Text(); strings.HasPrefix(line, "tx ") { // "tx {서명된 트랜잭션 JSON}" 은 mempool 로
+				io.WriteString(conn, api.AddTransaction(txPool, strings.TrimPrefix(line, "tx ")))
+				io.WriteString(conn, stakeStatus(addr)+"\nEnter a new BPM (or JSON/text payload): ")
+				continue
+			} else if line == "slots" { // 지금 slot, epoch 과 다가오는 제안자
+				io.WriteString(conn, slotStatus())
+				io.WriteString(conn, stakeStatus(addr)+"\nEnter a new BPM (or JSON/text payload): ")
+				continue
 			}
 
-			mutex.Lock()
-			oldLastIndex := Blockchain[len(Blockchain)-1]
-			mutex.Unlock()
+			payload := chain.ParsePayload(scanner.Text()) // 정수면 BPM, JSON 이면 JSON, 나머지는 바이트
 
-			newBlock, err := generateBlock(oldLastIndex, bpm, addr)
+			reveal, commit, ok := revealPair(conn, scanner, addr, pubKey) // 지갑으로 비밀값을 공개하고 다음 비밀값을 약속
+			if !ok {
+				return
+			}
+			newBlock, reused, err := propose(payload, addr, reveal, commit)
 			if err != nil {
-				log.Println(err)
-				io.WriteString(conn, "\nEnter a new BPM: ")
+				io.WriteString(conn, "\n"+err.Error())
+				io.WriteString(conn, stakeStatus(addr)+"\nEnter a new BPM (or JSON/text payload): ")
 				continue
 			}
-			if isBlockValid(newBlock, oldLastIndex) {
-				candidateBlocks <- newBlock
+			if reused {
+				io.WriteString(conn, "\nYou already proposed a block in this slot, sign the same block again (a second block would be slashed)\n")
 			}
 
-			io.WriteString(conn, "\nEnter token balance: ")
+			// 제안하는 블록의 해쉬에 검증자가 서명해야 후보로 인정
+			io.WriteString(conn, "Sign the block hash with your wallet: "+newBlock.Hash+"\nSignature: ")
+			if !scanner.Scan() {
+				return
+			}
+			if err := submit(newBlock, pubKey, strings.TrimSpace(scanner.Text())); err != nil {
+				io.WriteString(conn, err.Error()+"\n")
+			}
+
+			io.WriteString(conn, stakeStatus(addr)+"\nEnter a new BPM (or JSON/text payload): ")
 		}
 	}()
 
-	prev, err := json.Marshal(Blockchain)
+	prev, err := json.Marshal(Blockchain.Blocks())
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	for {
-		time.Sleep(10 * time.Second)
-		mutex.Lock()
-		output, err := json.Marshal(Blockchain)
-		mutex.Unlock()
+		select {
+		case <-done:
+			return
+		case <-time.After(10 * time.Second):
+		}
+		blocks := Blockchain.Blocks()
+		output, err := json.Marshal(blocks)
 		if err != nil {
 			log.Fatal(err)
 		}
 
 		if !bytes.Equal(prev, output) {
-			io.WriteString(conn, spew.Sdump(Blockchain))
+			io.WriteString(conn, spew.Sdump(blocks))
 			prev = output
 		}
 	}
 }
 
-func pickWinner() { // PoS 알고리즘 적용
-	time.Sleep(10 * time.Second)
+// PoS 알고리즘 적용: slot 이 끝날 때마다 그 slot 제안자의 후보 블록을 체인에 추가
+// 제안자는 epoch 이 시작할 때 체인에 bond 된 stake 로 정해진 일정에 따르므로, 어느 노드든 같은 검증자를 계산할 수 있음
+func pickWinner() {
+	slot := Blockchain.CurrentSlot()
+	time.Sleep(time.Until(genesisConfig.SlotTime(slot.Slot + 1)))
+
 	mutex.Lock()
 	temp := tempBlocks
+	tempBlocks = []chain.Block{}
+	for key, block := range built { // 끝난 slot 에 만든 블록은 더 서명할 일이 없음
+		if genesisConfig.Slot(block.Timestamp) <= slot.Slot {
+			delete(built, key)
+		}
+	}
 	mutex.Unlock()
 
-	lotteryPool := []string{}
-
-	if len(temp) > 0 {
+	tip := Blockchain.Tip()
+	for _, block := range temp {
+		if block.PrevHash != tip.Hash {
+			continue
+		}
+		if err := Blockchain.Append(block); err != nil { // 일정에 없는 검증자나 이미 지난 slot 의 블록
+			log.Println(err)
+			continue
+		}
+		txPool.Remove(block.Transactions)
 
-	OUTER:
-		// 블록은 생성한 노드 참가자 중 누가 많은 지분을 staking 했는지 체크
-		for _, block := range temp {
-			for _, node := range lotteryPool {
-				if block.Validator == node {
-					continue OUTER
-				}
+		next := Blockchain.Schedule(slot.Slot+1, 1)[0]
+		mutex.Lock()
+		for key, proposal := range proposals { // unbonding 기간이 지난 높이의 제안은 더 기억하지 않음
+			if proposal.Index+genesisConfig.UnbondingPeriod < block.Index {
+				delete(proposals, key)
 			}
+		}
+		mutex.Unlock()
 
-			mutex.Lock()
-			setValidators := validators
-			mutex.Unlock()
+		announce(Event{Type: EventBlock, Block: &block, Slot: &next})
+		return
+	}
+	if slot.Leader != "" { // 빈 slot 은 다음 블록이 추가될 때 제안자의 놓친 slot 으로 체인에 남음
+		log.Printf("pos: slot %d (epoch %d) missed by %s", slot.Slot, slot.Epoch, slot.Leader)
+		announce(Event{Type: EventMissed, Slot: &slot})
+	}
+}
 
-			k, ok := setValidators[block.Validator] // balance 조회
-			if ok {
-				for i := 0; i < k; i++ {
-					lotteryPool = append(lotteryPool, block.Validator)
-				}
-			}
-		}
+func generateBlock(oldBlock chain.Block, payload chain.Payload, addr, reveal, commit string) (chain.Block, error) { // 페이로드를 입력받아 블록 생성
+	if err := Blockchain.Validate(); err != nil {
+		return oldBlock, err
+	}
 
-		s := rand.NewSource(time.Now().Unix())
-		r := rand.New(s)
-		mutex.Lock()
-		lotteryWinner := lotteryPool[r.Intn(len(lotteryPool))]
-		mutex.Unlock()
+	newBlock := chain.NewBlock(oldBlock, payload, txPool.Batch(chain.MaxTransactions)...) // 대기 중인 트랜잭션을 함께 담음
+	newBlock.Validator = addr
+	newBlock.Reveal, newBlock.Commit = reveal, commit
+	newBlock.Hash = chain.CalculateHash(newBlock)
 
-		for _, block := range temp {
-			if block.Validator == lotteryWinner {
-				Blockchain = append(Blockchain, block)
+	return newBlock, nil
+}
 
-				for _ = range validators {
-					announcements <- "\nwinning validator: " + lotteryWinner + "\n"
-				}
-				break
-			}
-		}
+// 지금 slot 의 제안자만 블록을 만들 수 있음 - 만든 블록은 검증자가 해쉬에 서명한 뒤 submit
+// reveal, commit 은 검증자가 지갑으로 만든 비밀값의 공개와 다음 약속 (chain.RevealPair)
+// 같은 slot 에 다시 제안하면 새 블록 대신 처음 만든 블록을 돌려줌 (reused) - 서로 다른 두 블록에 서명하면 이중 제안으로 slash 됨
+func propose(payload chain.Payload, addr, reveal, commit string) (newBlock chain.Block, reused bool, err error) {
+	if err := payload.Validate(); err != nil {
+		return chain.Block{}, false, err
+	}
+	slot := Blockchain.CurrentSlot()
+	if slot.Leader != "" && slot.Leader != addr {
+		return chain.Block{}, false, fmt.Errorf("pos: not the leader for slot %d (leader: %s)", slot.Slot, slot.Leader)
 	}
 
+	key := addr + ":" + strconv.Itoa(slot.Slot)
 	mutex.Lock()
-	tempBlocks = []Block{}
-	mutex.Unlock()
+	defer mutex.Unlock()
+	if block, ok := built[key]; ok {
+		return block, true, nil
+	}
+	if err := Blockchain.CheckReveal(addr, reveal, commit); err != nil {
+		return chain.Block{}, false, err
+	}
+	newBlock, err = generateBlock(Blockchain.Tip(), payload, addr, reveal, commit)
+	if err != nil {
+		log.Println(err)
+		return newBlock, false, err
+	}
+	key = addr + ":" + strconv.Itoa(genesisConfig.Slot(newBlock.Timestamp)) // slot 이 끝나는 순간에 만들었으면 다음 slot 의 블록
+	if block, ok := built[key]; ok {
+		return block, true, nil
+	}
+	built[key] = newBlock
+	return newBlock, false, nil
 }
 
-func calculateHash(block Block) string { // 해쉬 생성
-	record := strconv.Itoa(block.Index) + block.Timestamp + strconv.Itoa(block.BPM) + block.PrevHash
-	h := sha256.New()
-	h.Write([]byte(record))
-	hashed := h.Sum(nil)
-	return hex.EncodeToString(hashed)
+func submit(newBlock chain.Block, pubKey, signature string) error { // 서명한 블록을 후보로
+	newBlock.PubKey, newBlock.Signature = pubKey, signature
+	if err := chain.IsBlockValid(newBlock, Blockchain.Tip()); err != nil {
+		return err
+	}
+	candidateBlocks <- newBlock
+	return nil
 }
 
-func isBlockValid(newBlock, oldBlock Block) bool { // 추가할 블록 변조 체크
-	if oldBlock.Index+1 != newBlock.Index {
-		return false
+// 후보 블록을 tempBlocks 에 담음 - 같은 검증자가 같은 높이, 같은 slot 에 다른 블록을 또 서명해서 내면 증거를 알림
+// 두 번째 블록은 propose 로 만든 것이 아니라 검증자가 직접 만들어 submit 한 것일 수 있음
+func receive(candidate chain.Block) {
+	key := candidate.Validator + ":" + strconv.Itoa(candidate.Index)
+	slot := genesisConfig.Slot(candidate.Timestamp)
+	mutex.Lock()
+	first, seen := proposals[key]
+	sameSlot := seen && genesisConfig.Slot(first.Timestamp) == slot
+	if !sameSlot { // 놓친 slot 뒤에 같은 높이를 다시 제안하는 것은 위반이 아님
+		proposals[key] = candidate
+		tempBlocks = append(tempBlocks, candidate)
+	}
+	mutex.Unlock()
+
+	if sameSlot && first.Hash != candidate.Hash {
+		reportEquivocation(chain.Evidence{First: chain.ProposalOf(first), Second: chain.ProposalOf(candidate)})
 	}
-	if oldBlock.Hash != newBlock.PrevHash {
-		return false
+}
+
+// 이중 제안 증거를 접속한 검증자에게 알림 - 누구든 "slash 증거" 로 트랜잭션을 만들어 제출하면 블록에 담겨 slash 됨
+func reportEquivocation(evidence chain.Evidence) {
+	log.Printf("pos: %s proposed two blocks at height %d", evidence.Offender(), evidence.First.Index)
+	announce(Event{Type: EventEquivocation, Evidence: &evidence})
+}
+
+func stakeStatus(addr string) string { // 잔액과 bond / unbonding 중인 stake, 놓친 slot 표시
+	st := validatorStatus(addr)
+	status := fmt.Sprintf("\nBalance: %d, bonded stake: %d, missed slots: %d", st.Balance, st.Stake, st.Missed)
+	if st.Jailed > 0 {
+		status += fmt.Sprintf("\nJailed: not elected until block %d", st.Jailed)
 	}
-	if calculateHash(newBlock) != newBlock.Hash {
-		return false
+	for _, u := range st.Unbonding {
+		status += fmt.Sprintf("\nUnbonding: %d (returns at block %d)", u.Amount, u.Release)
 	}
+	return status
+}
 
-	return true
+func slotStatus() string { // 지금 slot 부터 한 epoch 동안의 제안자
+	current := Blockchain.CurrentSlot()
+	status := fmt.Sprintf("\nSlot: %d, epoch: %d (slot %ds, epoch %d slots)", current.Slot, current.Epoch, genesisConfig.SlotDuration, genesisConfig.EpochLength)
+	for _, s := range Blockchain.Schedule(current.Slot, genesisConfig.EpochLength) {
+		status += fmt.Sprintf("\n  slot %d (epoch %d): %s", s.Slot, s.Epoch, leaderOf(s.Leader))
+	}
+	return status
 }
-func isBlockchainValid() error { // 전체 체인 변조 체크
-	currBlockIdx := len(Blockchain) - 1
-	prevBlockIdx := len(Blockchain) - 2
 
-	for prevBlockIdx >= 0 {
-		currBlock := Blockchain[currBlockIdx]
-		prevBlock := Blockchain[prevBlockIdx]
+func leaderOf(leader string) string { // bond 된 stake 가 없으면 누구나 제안할 수 있음
+	if leader == "" {
+		return "any validator"
+	}
+	return leader
+}
 
-		if currBlock.PrevHash != prevBlock.Hash {
-			return errors.New("blockchain has inconsistent hashes")
+// 검증자 등록: 지갑 공개키를 받고, 임의의 challenge 에 서명하게 하여 키의 주인임을 확인
+func register(conn net.Conn, scanner *bufio.Scanner) (string, string, bool) {
+	for {
+		io.WriteString(conn, "\nEnter wallet public key: ")
+		if !scanner.Scan() {
+			return "", "", false
 		}
+		pubKey := strings.TrimSpace(scanner.Text())
 
-		if currBlock.Timestamp <= prevBlock.Timestamp {
-			return errors.New("blockchain has inconsistent timestamps")
+		addr, err := wallet.AddressOf(pubKey)
+		if err != nil {
+			io.WriteString(conn, err.Error())
+			continue
 		}
 
-		if calculateHash(currBlock) != currBlock.Hash {
-			return errors.New("blockchain has inconsistent hash generation")
-		}
+		challenge := newChallenge()
 
-		currBlockIdx--
-		prevBlockIdx--
+		io.WriteString(conn, "Sign this challenge with your wallet: "+challenge+"\nSignature: ")
+		if !scanner.Scan() {
+			return "", "", false
+		}
+		if err := wallet.Verify(pubKey, []byte(challenge), strings.TrimSpace(scanner.Text())); err != nil {
+			io.WriteString(conn, err.Error())
+			continue
+		}
+		return addr, pubKey, true
 	}
-	return nil
 }
 
-func generateBlock(oldBlock Block, BPM int, addr string) (Block, error) { // BPM을 입력받아 블록 생성
-	if err := isBlockchainValid(); err != nil {
-		validators[addr] -= 5
-		return oldBlock, err
+// 검증자에게 앞서 약속한 비밀값 (round-1 번째 메시지의 서명) 과 다음 비밀값 (round 번째 메시지의 서명) 을 받아 공개값과 약속으로 만듦
+// 서명이 공개키와 맞는지 확인하므로 잘못 약속해서 다음 블록을 낼 수 없게 되는 일이 없음
+func revealPair(conn net.Conn, scanner *bufio.Scanner, addr, pubKey string) (string, string, bool) {
+	round := Blockchain.Round(addr)
+	sign := func(kind string, round int) (string, bool) {
+		msg := chain.RevealMessage(genesisConfig.ChainID, round)
+		for {
+			io.WriteString(conn, "Sign the "+kind+" message with your wallet: "+msg+"\nSignature: ")
+			if !scanner.Scan() {
+				return "", false
+			}
+			signature := strings.TrimSpace(scanner.Text())
+			if err := wallet.Verify(pubKey, []byte(msg), signature); err != nil {
+				io.WriteString(conn, err.Error()+"\n")
+				continue
+			}
+			return signature, true
+		}
 	}
 
-	var newBlock Block
-
-	t := time.Now()
+	var reveal string
+	if round > 0 {
+		var ok bool
+		if reveal, ok = sign("reveal", round-1); !ok {
+			return "", "", false
+		}
+	}
+	secret, ok := sign("commit", round)
+	if !ok {
+		return "", "", false
+	}
+	return reveal, chain.CommitOf(secret), true
+}
 
-	newBlock.Index = oldBlock.Index + 1
-	newBlock.Timestamp = t.String()
-	newBlock.BPM = BPM
-	newBlock.PrevHash = oldBlock.Hash
-	newBlock.Hash = calculateHash(newBlock)
-	newBlock.Validator = addr
+func newChallenge() string { // 등록할 때 서명할 임의의 값
+	b := make([]byte, 16)
+	crand.Read(b)
+	return hex.EncodeToString(b)
+}
 
-	return newBlock, nil
+func Audit() (chain.AuditReport, error) { // 저장된 체인 전체 검증 (CLI audit 명령)
+	genesis, err := chain.LoadGenesis()
+	if err != nil {
+		return chain.AuditReport{}, err
+	}
+	return storage.AuditChain("pos", genesis, validatorRule)
 }
 
-func randAddress() string { // 지갑 생성
-	b := make([]byte, 20)
-	rand.Seed(time.Now().UnixNano())
-	rand.Read(b)
-	return fmt.Sprintf("0x%x", b)
+// genesis 다음의 모든 블록은 검증자가 제안하고 서명해야 함 - 검증자가 없는 블록은 leader, 서명, commit-reveal 검사를 받지 않으므로 거부
+// 규칙을 넘긴 체인은 chain 의 ErrNoWork 검사를 하지 않으므로 목표값과 uncle 도 여기서 거부
+func validatorRule(blocks []chain.Block, newBlock chain.Block) error {
+	if newBlock.Validator == "" {
+		return chain.ErrUnsigned
+	}
+	if newBlock.Bits != 0 || len(newBlock.Uncles) > 0 {
+		return chain.ErrNoWork
+	}
+	return nil
 }
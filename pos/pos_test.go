@@ -0,0 +1,120 @@
+package pos
+
+import (
+	"testing"
+	"time"
+
+	"github.com/D0hwQ1/Blockchain-With-Go/chain"
+	"github.com/D0hwQ1/Blockchain-With-Go/wallet"
+)
+
+func TestValidatorRule(t *testing.T) {
+	w, err := wallet.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesis := chain.DefaultGenesis
+	genesis.Validators = map[string]int{w.Address(): 10}
+	genesisBlock := genesis.Block()
+
+	signed := func(block chain.Block) chain.Block {
+		block.Hash = chain.CalculateHash(block)
+		block.PubKey, block.Signature = w.PublicKey(), w.Sign([]byte(block.Hash))
+		return block
+	}
+	proposed := func() chain.Block {
+		block := chain.NewBlock(genesisBlock, chain.BPMPayload(1))
+		block.Validator = w.Address()
+		block.Reveal, block.Commit = chain.RevealPair(w, genesis.ChainID, 0)
+		return block
+	}
+
+	tests := []struct {
+		name  string
+		block chain.Block
+		err   error
+		rule  string // 감사 결과의 규칙
+	}{
+		{"no validator", chain.GenerateBlock(genesisBlock, chain.BPMPayload(1)), chain.ErrUnsigned, "signature"},
+		{"claimed work", func() chain.Block {
+			block := proposed()
+			block.Bits = genesis.Bits
+			block.TotalWork = chain.CumulativeWork(genesisBlock, block)
+			return signed(block)
+		}(), chain.ErrNoWork, "work"},
+		{"bad signature", func() chain.Block {
+			block := signed(proposed())
+			block.Signature = w.Sign([]byte("other"))
+			return block
+		}(), chain.ErrSigner, "signature"},
+		{"signed", signed(proposed()), nil, ""},
+	}
+	for _, test := range tests {
+		c := chain.New(&genesis, validatorRule)
+		if err := c.Append(test.block); err != test.err {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.err)
+		}
+		report := chain.AuditBlocks(&genesis, []chain.Block{genesisBlock, test.block}, validatorRule)
+		if report.Valid != (test.err == nil) || report.Rule != test.rule {
+			t.Errorf("%s: audit got %+v, want rule %q", test.name, report, test.rule)
+		}
+	}
+}
+
+func TestReceiveEquivocation(t *testing.T) {
+	w, err := wallet.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesis := chain.DefaultGenesis
+	genesisConfig = &genesis
+	genesisBlock := genesis.Block()
+	slotTime := func(slot int) int64 { return genesis.SlotTime(slot).UnixNano() }
+
+	block := func(bpm int, timestamp int64) chain.Block {
+		block := chain.NewBlock(genesisBlock, chain.BPMPayload(bpm))
+		block.Validator = w.Address()
+		block.Timestamp = timestamp
+		block.Hash = chain.CalculateHash(block)
+		block.Sign(w)
+		return block
+	}
+
+	tests := []struct {
+		name       string
+		second     chain.Block
+		candidates int  // tempBlocks 에 담긴 후보 수
+		evidence   bool // 이중 제안 알림
+	}{
+		{"same block again", block(1, slotTime(5)), 1, false},
+		{"other block in the same slot", block(2, slotTime(5)+int64(time.Second)), 1, true},
+		{"same height in a later slot", block(2, slotTime(6)), 2, false},
+	}
+	for _, test := range tests {
+		proposals = make(map[string]chain.Block)
+		tempBlocks = []chain.Block{}
+		events := subscribe()
+
+		receive(block(1, slotTime(5)))
+		receive(test.second)
+
+		if len(tempBlocks) != test.candidates {
+			t.Errorf("%s: %d candidates, want %d", test.name, len(tempBlocks), test.candidates)
+		}
+		var event *Event
+		select {
+		case e := <-events:
+			event = &e
+		default:
+		}
+		if (event != nil) != test.evidence {
+			t.Errorf("%s: got event %v, want evidence %v", test.name, event, test.evidence)
+		}
+		if event != nil {
+			if event.Type != EventEquivocation || event.Evidence.Verify() != nil {
+				t.Errorf("%s: got %+v, want verifiable evidence", test.name, event)
+			}
+		}
+		unsubscribe(events)
+	}
+}
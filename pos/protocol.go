@@ -0,0 +1,358 @@
+package pos
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/D0hwQ1/Blockchain-With-Go/chain"
+	"github.com/D0hwQ1/Blockchain-With-Go/wallet"
+)
+
+// 검증자 프로그램용 프로토콜: 한 줄에 JSON 하나씩 요청 (Request) 을 보내고 같은 ID 의 응답 (Response) 을 받음
+// 접속하자마자 '{' 로 시작하는 요청을 보내면 이 프로토콜, 아니면 사람용 대화형 모드
+//
+//	hello                               -> Hello
+//	challenge                           -> Challenge
+//	register  {PubKey, Signature}       -> Registered (challenge 에 서명)
+//	status    {Address}                 -> Status (Address 가 비어 있으면 등록한 주소)
+//	slots                               -> Schedule
+//	tx        Transaction               -> Submitted (서명된 트랜잭션을 mempool 로)
+//	stake     Transaction               -> Submitted (stake 페이로드의 트랜잭션만)
+//	propose   {Payload, Reveal, Commit} -> chain.Block (서명 전, 등록한 검증자만 - 같은 slot 에 다시 요청하면 처음 만든 블록)
+//	submit    {Hash, Signature}         -> Submitted (propose 로 받은 블록의 해쉬에 서명해서 후보로 제출)
+//	submit    {Block}                   -> Submitted (검증자가 직접 만들고 서명한 블록을 후보로 제출)
+//	subscribe                           -> Subscribed, 이후 Event 가 담긴 응답 (ID 0) 을 계속 받음
+const ProtocolVersion = 3
+
+const joinWait = 500 * time.Millisecond // 접속 후 프로토콜 요청을 기다리는 시간
+
+var (
+	errMethod       = errors.New("pos: unknown method")
+	errParams       = errors.New("pos: invalid params")
+	errChallenge    = errors.New("pos: request a challenge first")
+	errUnregistered = errors.New("pos: register as a validator first")
+	errNoStake      = errors.New("pos: transaction is not a stake action")
+	errNoProposal   = errors.New("pos: no such proposed block")
+	errNotOwnBlock  = errors.New("pos: block is not signed by the registered validator")
+)
+
+type Request struct {
+	ID     int
+	Method string
+	Params json.RawMessage `json:",omitempty"`
+}
+
+// 요청의 응답, 또는 구독한 알림 (Event 가 있으면 ID 는 0)
+type Response struct {
+	ID     int
+	Result json.RawMessage `json:",omitempty"`
+	Error  string          `json:",omitempty"`
+	Event  *Event          `json:",omitempty"`
+}
+
+type Hello struct {
+	Version int
+	ChainID string
+	Height  int // 다음 블록의 Index
+	Tip     string
+	Slot    chain.SlotLeader
+	Genesis chain.GenesisConfig // 블록 시간이 속한 slot 을 계산할 때 씀
+}
+
+type Challenge struct {
+	Challenge string
+}
+
+type RegisterParams struct {
+	PubKey    string
+	Signature string // Challenge 에 대한 서명
+}
+
+type Registered struct {
+	Address string
+}
+
+type StatusParams struct {
+	Address string
+}
+
+type Status struct {
+	Address   string
+	Balance   int
+	Stake     int
+	Unbonding []chain.Unbonding
+	Jailed    int // leader 선출에 다시 들어가는 높이 (감옥에 있지 않으면 0)
+	Missed    int // 놓친 slot 수
+	Round     int // 약속한 비밀값 수 (다음 블록의 chain.RevealPair 에 씀)
+}
+
+type Schedule struct {
+	Current  chain.SlotLeader
+	Upcoming []chain.SlotLeader // 지금 slot 부터 한 epoch 동안
+}
+
+type ProposeParams struct {
+	Payload chain.Payload
+	Reveal  string // 앞서 약속한 비밀값 (chain.RevealPair)
+	Commit  string // 다음 비밀값의 약속
+}
+
+type SubmitParams struct {
+	Hash      string
+	Signature string
+	Block     *chain.Block `json:",omitempty"` // 있으면 Hash, Signature 대신 이 블록을 그대로 제출
+}
+
+type Submitted struct {
+	Hash string // 트랜잭션 또는 블록 해쉬
+}
+
+type Subscribed struct{}
+
+// 접속한 검증자에게 알리는 일
+const (
+	EventBlock        = "block"        // 블록이 추가됨 (Block, 다음 slot 의 제안자 Slot)
+	EventMissed       = "missed"       // 제안자가 블록을 내지 않은 slot (Slot)
+	EventEquivocation = "equivocation" // 이중 제안 증거 (Evidence), slash 트랜잭션으로 제출할 수 있음
+)
+
+type Event struct {
+	Type     string
+	Block    *chain.Block      `json:",omitempty"`
+	Slot     *chain.SlotLeader `json:",omitempty"`
+	Evidence *chain.Evidence   `json:",omitempty"`
+}
+
+func (e Event) String() string { // 대화형 모드에서 보여주는 형태
+	switch e.Type {
+	case EventBlock:
+		return "\nwinning validator: " + e.Block.Validator + " (slot " + strconv.Itoa(genesisConfig.Slot(e.Block.Timestamp)) + ")" +
+			"\nnext leader: " + leaderOf(e.Slot.Leader) + " (slot " + strconv.Itoa(e.Slot.Slot) + ")\n"
+	case EventMissed:
+		return "\nslot " + strconv.Itoa(e.Slot.Slot) + " missed by " + e.Slot.Leader + "\n"
+	case EventEquivocation:
+		data, _ := json.Marshal(e.Evidence)
+		return "\nequivocation by " + e.Evidence.Offender() + " at block " + strconv.Itoa(e.Evidence.First.Index) + "\nslash " + string(data) + "\n"
+	}
+	return ""
+}
+
+var subscribers = make(map[chan Event]bool) // 알림을 받는 접속
+
+func subscribe() chan Event {
+	events := make(chan Event, 16)
+	mutex.Lock()
+	subscribers[events] = true
+	mutex.Unlock()
+	return events
+}
+
+func unsubscribe(events chan Event) {
+	mutex.Lock()
+	delete(subscribers, events)
+	mutex.Unlock()
+	close(events)
+}
+
+func announce(event Event) { // 모든 구독자에게 알림 (읽지 못하고 밀린 접속은 건너뜀)
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	for events := range subscribers {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// 접속하자마자 '{' 를 보낸 클라이언트인지 확인
+func isProtocolClient(conn net.Conn, reader *bufio.Reader) bool {
+	conn.SetReadDeadline(time.Now().Add(joinWait))
+	defer conn.SetReadDeadline(time.Time{})
+
+	b, err := reader.Peek(1)
+	return err == nil && b[0] == '{'
+}
+
+// 한 접속의 프로토콜 상태
+type session struct {
+	conn      net.Conn
+	write     sync.Mutex
+	challenge string
+	addr      string
+	pubKey    string
+	proposed  map[string]chain.Block // propose 로 만들고 아직 서명받지 않은 블록
+	events    chan Event
+}
+
+func serveProtocol(conn net.Conn, reader *bufio.Reader) {
+	s := &session{conn: conn, proposed: make(map[string]chain.Block)}
+	defer func() {
+		if s.events != nil {
+			unsubscribe(s.events)
+		}
+	}()
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*chain.MaxPayloadSize)
+	for scanner.Scan() {
+		var req Request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			s.send(Response{Error: err.Error()})
+			continue
+		}
+
+		res := Response{ID: req.ID}
+		result, err := s.handle(req)
+		if err != nil {
+			res.Error = err.Error()
+		} else if res.Result, err = json.Marshal(result); err != nil {
+			res.Error = err.Error()
+		}
+		s.send(res)
+	}
+}
+
+func (s *session) send(res Response) {
+	data, err := json.Marshal(res)
+	if err != nil {
+		return
+	}
+	s.write.Lock()
+	defer s.write.Unlock()
+	s.conn.Write(append(data, '\n'))
+}
+
+func (s *session) handle(req Request) (interface{}, error) {
+	switch req.Method {
+	case "hello":
+		return Hello{ProtocolVersion, genesisConfig.ChainID, Blockchain.Len(), Blockchain.Tip().Hash, Blockchain.CurrentSlot(), *genesisConfig}, nil
+
+	case "challenge":
+		s.challenge = newChallenge()
+		return Challenge{s.challenge}, nil
+
+	case "register":
+		var params RegisterParams
+		if json.Unmarshal(req.Params, &params) != nil {
+			return nil, errParams
+		}
+		if s.challenge == "" {
+			return nil, errChallenge
+		}
+		addr, err := wallet.AddressOf(params.PubKey)
+		if err != nil {
+			return nil, err
+		}
+		if err := wallet.Verify(params.PubKey, []byte(s.challenge), params.Signature); err != nil {
+			return nil, err
+		}
+		s.challenge = ""
+		s.addr, s.pubKey = addr, params.PubKey
+		return Registered{addr}, nil
+
+	case "status":
+		var params StatusParams
+		if len(req.Params) > 0 && json.Unmarshal(req.Params, &params) != nil {
+			return nil, errParams
+		}
+		if params.Address == "" {
+			params.Address = s.addr
+		}
+		if !wallet.IsAddress(params.Address) {
+			return nil, errParams
+		}
+		return validatorStatus(params.Address), nil
+
+	case "slots":
+		current := Blockchain.CurrentSlot()
+		return Schedule{current, Blockchain.Schedule(current.Slot, genesisConfig.EpochLength)}, nil
+
+	case "tx", "stake":
+		var tx chain.Transaction
+		if json.Unmarshal(req.Params, &tx) != nil {
+			return nil, errParams
+		}
+		if req.Method == "stake" && tx.Payload.Type != chain.TypeStake {
+			return nil, errNoStake
+		}
+		if err := txPool.Add(tx); err != nil {
+			return nil, err
+		}
+		return Submitted{tx.Hash()}, nil
+
+	case "propose":
+		var params ProposeParams
+		if json.Unmarshal(req.Params, &params) != nil {
+			return nil, errParams
+		}
+		if s.addr == "" {
+			return nil, errUnregistered
+		}
+		newBlock, _, err := propose(params.Payload, s.addr, params.Reveal, params.Commit)
+		if err != nil {
+			return nil, err
+		}
+		s.proposed = map[string]chain.Block{newBlock.Hash: newBlock} // 서명받지 못한 이전 제안은 버림
+		return newBlock, nil
+
+	case "submit":
+		var params SubmitParams
+		if json.Unmarshal(req.Params, &params) != nil {
+			return nil, errParams
+		}
+		if params.Block != nil {
+			if s.addr == "" {
+				return nil, errUnregistered
+			}
+			if params.Block.Validator != s.addr || params.Block.PubKey != s.pubKey {
+				return nil, errNotOwnBlock
+			}
+			if err := submit(*params.Block, params.Block.PubKey, params.Block.Signature); err != nil {
+				return nil, err
+			}
+			return Submitted{params.Block.Hash}, nil
+		}
+		newBlock, ok := s.proposed[params.Hash]
+		if !ok {
+			return nil, errNoProposal
+		}
+		delete(s.proposed, params.Hash)
+		if err := submit(newBlock, s.pubKey, params.Signature); err != nil {
+			return nil, err
+		}
+		return Submitted{newBlock.Hash}, nil
+
+	case "subscribe":
+		if s.events == nil {
+			s.events = subscribe()
+			go func(events chan Event) {
+				for event := range events {
+					event := event
+					s.send(Response{Event: &event})
+				}
+			}(s.events)
+		}
+		return Subscribed{}, nil
+	}
+	return nil, fmt.Errorf("%w %q", errMethod, req.Method)
+}
+
+func validatorStatus(addr string) Status { // 잔액, stake, 감옥, 놓친 slot
+	return Status{
+		Address:   addr,
+		Balance:   Blockchain.Balance(addr),
+		Stake:     Blockchain.Stake(addr),
+		Unbonding: Blockchain.Unbonding(addr),
+		Jailed:    Blockchain.Jailed(addr),
+		Missed:    Blockchain.Missed()[addr],
+		Round:     Blockchain.Round(addr),
+	}
+}
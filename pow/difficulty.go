@@ -0,0 +1,86 @@
+package pow
+
+import (
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/D0hwQ1/Blockchain-With-Go/chain"
+)
+
+var (
+	errRetarget  = errors.New("block target does not follow the retarget schedule")
+	errMaxTarget = errors.New("block target is above the allowed maximum")
+)
+
+// 한 번에 목표값을 1/4 ~ 4배 까지만 조정 - 시간이 조작된 구간 하나로 난이도가 급변하지 않음
+const retargetFactor = 4
+
+// 새 블록(blocks 다음 블록)이 가져야 하는 목표값
+// RetargetInterval 블록마다 직전 RetargetInterval 개 블록 간격의 합에 비례하도록 조정: 새 목표값 = 목표값 * 실제 시간 / 목표 시간
+func nextBits(genesis *chain.GenesisConfig, blocks []chain.Block) chain.Compact {
+	last := blocks[len(blocks)-1]
+	interval := genesis.RetargetInterval
+	height := len(blocks)
+
+	// genesis 블록의 시간은 고정값이라 구간 계산에 넣지 않음, 목표 간격이 없으면 (0) 조정하지 않음
+	if interval < 1 || genesis.TargetBlockTime < 1 || height%interval != 0 || height-interval-1 < 1 {
+		return last.Bits
+	}
+
+	first := blocks[height-interval-1]
+	elapsed := time.Duration(last.Timestamp - first.Timestamp)
+	expected := time.Duration(interval) * time.Duration(genesis.TargetBlockTime) * time.Second
+
+	if elapsed < expected/retargetFactor {
+		elapsed = expected / retargetFactor
+	}
+	if elapsed > expected*retargetFactor {
+		elapsed = expected * retargetFactor
+	}
+
+	target := last.Bits.Target()
+	target.Mul(target, big.NewInt(int64(elapsed)))
+	target.Div(target, big.NewInt(int64(expected)))
+	if maxTarget := genesis.MaxBits.Target(); target.Cmp(maxTarget) > 0 {
+		target = maxTarget
+	}
+	return chain.CompactOf(target)
+}
+
+// 블록과 블록이 담은 uncle 이 조정된 목표값을 따르고 해쉬가 그 목표값 이하인지 체크 - 피어가 더 쉬운 블록을 보낼 수 없음
+func workRule(genesis *chain.GenesisConfig) chain.Rule {
+	return func(blocks []chain.Block, newBlock chain.Block) error {
+		if err := checkWork(genesis, blocks, newBlock); err != nil {
+			return err
+		}
+		for _, uncle := range newBlock.Uncles { // uncle 은 자기 높이의 목표값을 따라야 함
+			if uncle.Index < 1 || uncle.Index >= len(blocks) {
+				return chain.ErrUncle
+			}
+			if err := checkWork(genesis, blocks[:uncle.Index], uncle.Block()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// blocks 는 newBlock 의 부모까지의 체인
+func checkWork(genesis *chain.GenesisConfig, blocks []chain.Block, newBlock chain.Block) error {
+	powHash, err := Algorithm(genesis.PowHash)
+	if err != nil {
+		return err
+	}
+	target := newBlock.Bits.Target()
+	if target == nil || target.Cmp(genesis.MaxBits.Target()) > 0 {
+		return errMaxTarget
+	}
+	if newBlock.Bits != nextBits(genesis, blocks) {
+		return errRetarget
+	}
+	if !chain.MeetsTarget(powHash(newBlock.Preimage()), target) {
+		return errDifficulty
+	}
+	return nil
+}
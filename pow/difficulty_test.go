@@ -0,0 +1,43 @@
+package pow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/D0hwQ1/Blockchain-With-Go/chain"
+)
+
+func TestNextBits(t *testing.T) {
+	tests := []struct {
+		name     string
+		height   int           // 새 블록의 높이 (blocks 길이)
+		gap      time.Duration // 블록 간격
+		bits     chain.Compact // 지금 목표값
+		interval int
+		target   int // TargetBlockTime
+		want     chain.Compact
+	}{
+		{"not a retarget height", 7, time.Second, 0x1d00ffff, 4, 10, 0x1d00ffff},
+		{"first interval includes genesis", 4, time.Second, 0x1d00ffff, 4, 10, 0x1d00ffff},
+		{"on schedule", 8, 10 * time.Second, 0x1d00ffff, 4, 10, 0x1d00ffff},
+		{"twice as slow", 8, 20 * time.Second, 0x1d00ffff, 4, 10, 0x1d01fffe},
+		{"too fast clamps to 1/4", 8, time.Second, 0x1d00ffff, 4, 10, 0x1c3fffc0},
+		{"too slow clamps to 4x", 8, 100 * time.Second, 0x1d00ffff, 4, 10, 0x1d03fffc},
+		{"capped at MaxBits", 8, 100 * time.Second, 0x1f7fffff, 4, 10, 0x1f7fffff},
+		{"no retarget interval", 8, time.Second, 0x1d00ffff, 0, 10, 0x1d00ffff},
+		{"no target block time", 8, time.Second, 0x1d00ffff, 4, 0, 0x1d00ffff},
+	}
+	for _, test := range tests {
+		genesis := chain.DefaultGenesis
+		genesis.RetargetInterval, genesis.TargetBlockTime = test.interval, test.target
+
+		blocks := make([]chain.Block, test.height)
+		for i := range blocks {
+			blocks[i].Timestamp = int64(i) * int64(test.gap)
+			blocks[i].Bits = test.bits
+		}
+		if got := nextBits(&genesis, blocks); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package pow
+
+import (
+	"crypto/sha256"
+	"errors"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/scrypt"
+	"lukechampine.com/blake3"
+)
+
+var errAlgorithm = errors.New("unknown proof-of-work hash algorithm")
+
+// 블록의 해쉬 입력(chain.Block.Preimage)으로 PoW 해쉬(32바이트)를 계산하는 함수
+// 블록 식별자(Hash, PrevHash)는 알고리즘과 상관없이 sha256 이고, 목표값과 비교하는 값만 이 함수로 계산
+type HashFunc func(data []byte) []byte
+
+// genesis 의 PowHash 로 고르는 알고리즘 - CPU 위주(sha256, sha256d, blake3)와 메모리 위주(scrypt, argon2id)
+var algorithms = map[string]HashFunc{
+	"sha256":   sha256Hash,
+	"sha256d":  sha256dHash,
+	"blake3":   blake3Hash,
+	"scrypt":   scryptHash,
+	"argon2id": argon2Hash,
+}
+
+func Algorithm(name string) (HashFunc, error) { // 비어 있으면 sha256
+	if name == "" {
+		name = "sha256"
+	}
+	hash, ok := algorithms[name]
+	if !ok {
+		return nil, errAlgorithm
+	}
+	return hash, nil
+}
+
+func sha256Hash(data []byte) []byte {
+	h := sha256.Sum256(data)
+	return h[:]
+}
+
+func sha256dHash(data []byte) []byte { // 비트코인처럼 sha256 을 두 번
+	first := sha256.Sum256(data)
+	h := sha256.Sum256(first[:])
+	return h[:]
+}
+
+func blake3Hash(data []byte) []byte {
+	h := blake3.Sum256(data)
+	return h[:]
+}
+
+// 라이트코인과 같은 매개변수 (N=1024, r=1, p=1, 128KiB 메모리), 입력을 salt 로도 사용
+func scryptHash(data []byte) []byte {
+	h, _ := scrypt.Key(data, data, 1024, 1, 1, 32)
+	return h
+}
+
+// 해쉬 한 번에 4MiB 메모리, salt 는 입력의 sha256
+func argon2Hash(data []byte) []byte {
+	salt := sha256.Sum256(data)
+	return argon2.IDKey(data, salt[:], 1, 4*1024, 1, 32)
+}
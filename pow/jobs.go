@@ -0,0 +1,148 @@
+package pow
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/D0hwQ1/Blockchain-With-Go/chain"
+	"github.com/davecgh/go-spew/spew"
+)
+
+const (
+	maxQueuedJobs = 100              // 채굴을 기다릴 수 있는 요청 수
+	jobTTL        = 10 * time.Minute // 끝난 작업을 조회할 수 있는 시간
+)
+
+// 채굴 작업 상태
+const (
+	jobQueued = "queued"
+	jobMining = "mining"
+	jobDone   = "done"
+	jobFailed = "failed"
+)
+
+var errQueueFull = errors.New("mining queue is full")
+
+// POST / 로 들어온 블록 생성 요청 - GET /jobs/{id} 로 상태와 채굴된 블록을 조회
+type Job struct {
+	ID       string
+	Status   string
+	Payload  chain.Payload
+	Block    *chain.Block `json:",omitempty"` // 채굴이 끝나 체인에 추가된 블록
+	Error    string       `json:",omitempty"`
+	Created  time.Time
+	Finished *time.Time `json:",omitempty"` // 끝나기 전에는 nil
+}
+
+var (
+	jobs      = make(map[string]*Job)
+	jobsMutex = &sync.Mutex{}
+	jobQueue  = make(chan string, maxQueuedJobs)
+)
+
+func submitJob(payload chain.Payload) (Job, error) { // 작업을 만들어 대기열에 넣음
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return Job{}, err
+	}
+	job := &Job{ID: hex.EncodeToString(b), Status: jobQueued, Payload: payload, Created: time.Now()}
+
+	jobsMutex.Lock()
+	pruneJobs()
+	jobs[job.ID] = job
+	jobsMutex.Unlock()
+
+	select {
+	case jobQueue <- job.ID:
+		return *job, nil
+	default:
+		jobsMutex.Lock()
+		delete(jobs, job.ID)
+		jobsMutex.Unlock()
+		return Job{}, errQueueFull
+	}
+}
+
+func getJob(id string) (Job, bool) {
+	jobsMutex.Lock()
+	defer jobsMutex.Unlock()
+
+	job, ok := jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+func pruneJobs() { // 오래전에 끝난 작업 삭제 (jobsMutex 를 잡은 상태에서 호출)
+	for id, job := range jobs {
+		if job.Finished != nil && time.Since(*job.Finished) > jobTTL {
+			delete(jobs, id)
+		}
+	}
+}
+
+func updateJob(id string, update func(job *Job)) {
+	jobsMutex.Lock()
+	defer jobsMutex.Unlock()
+
+	if job, ok := jobs[id]; ok {
+		update(job)
+	}
+}
+
+// 대기열의 작업을 들어온 순서대로 하나씩 채굴 - 블록은 항상 현재 체인 끝에 이어지므로 차례로 처리
+func runJobs() {
+	for id := range jobQueue {
+		job, ok := getJob(id)
+		if !ok {
+			continue
+		}
+		updateJob(id, func(job *Job) { job.Status = jobMining })
+
+		newBlock, err := mineJob(job.Payload)
+		updateJob(id, func(job *Job) {
+			finished := time.Now()
+			job.Finished = &finished
+			if err != nil {
+				job.Status, job.Error = jobFailed, err.Error()
+				return
+			}
+			job.Status, job.Block = jobDone, &newBlock
+		})
+	}
+}
+
+func mineJob(payload chain.Payload) (chain.Block, error) {
+	for {
+		newBlock, err := generateBlock(context.Background(), Blockchain.Blocks(), payload, txPool.Batch(chain.MaxTransactions))
+		if errors.Is(err, context.Canceled) { // 채굴 중에 다른 블록이 먼저 추가됨 - 새 체인 끝에서 다시 채굴
+			log.Println("pow: tip changed, mining again")
+			continue
+		}
+		if err != nil {
+			return newBlock, err
+		}
+
+		mutex.Lock()
+		if Blockchain.Tip().Hash != newBlock.PrevHash { // 채굴을 끝낸 직후 다른 블록이 먼저 추가됨 - uncle 로 남기고 다시 채굴
+			mutex.Unlock()
+			addStale(newBlock)
+			continue
+		}
+		if err := Blockchain.Append(newBlock); err != nil {
+			mutex.Unlock()
+			return newBlock, err
+		}
+		txPool.Remove(newBlock.Transactions)
+		spew.Dump(Blockchain.Blocks())
+		mutex.Unlock()
+
+		return newBlock, nil
+	}
+}
@@ -0,0 +1,105 @@
+package pow
+
+import (
+	"context"
+	"log"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/D0hwQ1/Blockchain-With-Go/chain"
+)
+
+const tipPollInterval = 100 * time.Millisecond // 다른 블록이 먼저 추가되었는지 확인하는 주기
+
+// block 의 목표값을 만족하는 nonce 를 CPU 코어 수만큼의 worker 로 찾음
+// worker i 는 i, i+workers, i+2*workers ... 를 대입하므로 nonce 공간이 겹치지 않음
+// ctx 가 취소되면 찾기를 멈추고 ctx.Err() 를 반환
+func mine(ctx context.Context, block chain.Block, powHash HashFunc) (chain.Block, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers := runtime.NumCPU()
+	target := block.Bits.Target()
+	var attempts uint64
+	found := make(chan chain.Block, 1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(start uint64) {
+			defer wg.Done()
+			candidate := block
+			for n := uint64(0); ; n++ {
+				select {
+				case <-ctx.Done():
+					return
+				default:
+				}
+				candidate.Nonce = strconv.FormatUint(start+n*uint64(workers), 16)
+				atomic.AddUint64(&attempts, 1)
+				if chain.MeetsTarget(powHash(candidate.Preimage()), target) {
+					candidate.Hash = chain.CalculateHash(candidate)
+					select {
+					case found <- candidate:
+						cancel()
+					default: // 다른 worker 가 먼저 찾음
+					}
+					return
+				}
+			}
+		}(uint64(i))
+	}
+
+	go reportHashRate(ctx, &attempts)
+
+	started := time.Now()
+	wg.Wait()
+	select {
+	case newBlock := <-found:
+		log.Printf("pow: block %d mined after %d attempts in %v", newBlock.Index, atomic.LoadUint64(&attempts), time.Since(started).Round(time.Millisecond))
+		return newBlock, nil
+	default:
+		return block, ctx.Err()
+	}
+}
+
+func reportHashRate(ctx context.Context, attempts *uint64) { // 1초마다 초당 해쉬 시도 횟수 출력
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	var last uint64
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			total := atomic.LoadUint64(attempts)
+			log.Printf("pow: %d hashes/s", total-last)
+			last = total
+		}
+	}
+}
+
+// 채굴하는 동안 체인의 마지막 블록이 prevHash 가 아니게 되면 (다른 블록이 먼저 추가되면) 취소되는 context
+func untilTipChanges(ctx context.Context, prevHash string) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		ticker := time.NewTicker(tipPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if Blockchain.Tip().Hash != prevHash {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+	return ctx, cancel
+}
@@ -0,0 +1,278 @@
+package pow
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/D0hwQ1/Blockchain-With-Go/api"
+	"github.com/D0hwQ1/Blockchain-With-Go/chain"
+	"github.com/D0hwQ1/Blockchain-With-Go/wallet"
+)
+
+const (
+	shareFactor    = 256              // share 목표값 = 블록 목표값 * shareFactor (share 는 블록보다 256배 쉬움)
+	pplnsWindow    = 1000             // 블록 보상을 나누는 최근 share 수 (PPLNS 의 N)
+	hashrateWindow = 10 * time.Minute // worker 해쉬레이트를 계산하는 기간
+)
+
+var (
+	errNoPool    = errors.New("pool mode needs MINER_ADDRESS to collect block rewards")
+	errWorker    = errors.New("worker must be a wallet address")
+	errShare     = errors.New("share hash is above the share target")
+	errDuplicate = errors.New("share was already submitted")
+)
+
+// pool 작업: getwork 템플릿 + 더 쉬운 share 목표값
+type PoolWork struct {
+	Work
+	ShareTarget string // 64자리 hex, 해쉬가 이 값 이하이면 share 로 인정 (Target 이하이면 블록도 찾은 것)
+}
+
+// worker 가 찾은 nonce - Worker 는 보상을 받을 지갑 주소
+type Share struct {
+	ID     string
+	Worker string
+	Nonce  string
+}
+
+type ShareResult struct {
+	Accepted bool
+	Block    *chain.Block `json:",omitempty"` // share 가 블록 목표값도 만족하여 체인에 추가된 블록
+}
+
+type shareRecord struct {
+	worker string
+	work   *big.Int // share 목표값의 작업량 - share 목표값이 바뀌어도 비율을 맞춤
+	time   time.Time
+}
+
+// worker 별 상태 (GET /pool)
+type WorkerStatus struct {
+	Worker    string
+	Shares    int     // 지금까지 인정된 share 수
+	Hashrate  float64 // 최근 hashrateWindow 동안의 초당 해쉬 추정치
+	Pending   int     // 아직 지급되지 않은 보상
+	LastShare time.Time
+}
+
+type PoolStatus struct {
+	Address     string // 블록 보상을 받는 pool 주소 (MINER_ADDRESS)
+	ShareFactor int
+	Window      int // PPLNS 의 N
+	Blocks      []string
+	Workers     []WorkerStatus
+}
+
+type poolWorker struct {
+	shares    int
+	pending   int
+	lastShare time.Time
+}
+
+// share 기록과 worker 별 지급 예정 보상
+var (
+	poolMutex   = &sync.Mutex{}
+	poolShares  []shareRecord                      // 최근 share (최대 pplnsWindow 개)
+	poolSeen    = make(map[string]map[string]bool) // 템플릿별로 이미 받은 nonce
+	poolWorkers = make(map[string]*poolWorker)
+	poolBlocks  []string // pool 이 찾은 블록 해쉬
+)
+
+func shareTarget(bits chain.Compact) *big.Int { // 블록 목표값보다 shareFactor 배 쉬운 목표값 (최대 2^256 - 1)
+	target := bits.Target()
+	target.Mul(target, big.NewInt(shareFactor))
+	if max := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1)); target.Cmp(max) > 0 {
+		target = max
+	}
+	return target
+}
+
+func getPoolWork() (PoolWork, error) {
+	if minerAddress == "" {
+		return PoolWork{}, errNoPool
+	}
+	work, err := getWork(minerAddress)
+	if err != nil {
+		return PoolWork{}, err
+	}
+	return PoolWork{work, hex.EncodeToString(shareTarget(work.Header.Bits).FillBytes(make([]byte, 32)))}, nil
+}
+
+// share 를 검증하여 기록하고, 블록 목표값도 만족하면 블록을 추가한 뒤 보상을 최근 share 에 나눔
+func submitShare(share Share) (ShareResult, error) {
+	if minerAddress == "" {
+		return ShareResult{}, errNoPool
+	}
+	if !wallet.IsAddress(share.Worker) {
+		return ShareResult{}, errWorker
+	}
+
+	newBlock, hash, err := solve(Solution{share.ID, share.Nonce})
+	if err != nil {
+		return ShareResult{}, err
+	}
+	if newBlock.Miner != minerAddress { // getwork 로 받은 다른 주소의 템플릿
+		return ShareResult{}, errStaleWork
+	}
+	if newBlock.PrevHash != Blockchain.Tip().Hash { // 체인 끝이 바뀐 템플릿의 share 는 PPLNS 창에 넣지 않음
+		return ShareResult{}, errStaleWork
+	}
+	target := shareTarget(newBlock.Bits)
+	if !chain.MeetsTarget(hash, target) {
+		return ShareResult{}, errShare
+	}
+	if err := recordShare(share, target); err != nil {
+		return ShareResult{}, err
+	}
+
+	if !chain.MeetsTarget(hash, newBlock.Bits.Target()) {
+		return ShareResult{Accepted: true}, nil
+	}
+	if err := appendSolved(newBlock); err != nil {
+		return ShareResult{Accepted: true}, err
+	}
+	payout(newBlock)
+	return ShareResult{Accepted: true, Block: &newBlock}, nil
+}
+
+func recordShare(share Share, target *big.Int) error {
+	poolMutex.Lock()
+	defer poolMutex.Unlock()
+
+	pruneSeen()
+	if poolSeen[share.ID] == nil {
+		poolSeen[share.ID] = make(map[string]bool)
+	}
+	if poolSeen[share.ID][share.Nonce] {
+		return errDuplicate
+	}
+	poolSeen[share.ID][share.Nonce] = true
+
+	space := new(big.Int).Lsh(big.NewInt(1), 256)
+	poolShares = append(poolShares, shareRecord{share.Worker, space.Div(space, target.Add(target, big.NewInt(1))), time.Now()})
+	if len(poolShares) > pplnsWindow {
+		poolShares = poolShares[len(poolShares)-pplnsWindow:]
+	}
+
+	worker, ok := poolWorkers[share.Worker]
+	if !ok {
+		worker = &poolWorker{}
+		poolWorkers[share.Worker] = worker
+	}
+	worker.shares++
+	worker.lastShare = time.Now()
+	return nil
+}
+
+// 만료된 템플릿의 share 는 다시 제출해도 solve 에서 거부되므로 중복 기록을 지움 (poolMutex 를 잡은 상태에서 호출)
+func pruneSeen() {
+	templatesMutex.Lock()
+	defer templatesMutex.Unlock()
+
+	for id := range poolSeen {
+		if _, ok := templates[id]; !ok {
+			delete(poolSeen, id)
+		}
+	}
+}
+
+// PPLNS: 블록 보상을 최근 pplnsWindow 개 share 의 작업량 비율로 나눔 (나머지는 블록을 찾은 share 의 worker 에게)
+func payout(block chain.Block) {
+	poolMutex.Lock()
+	defer poolMutex.Unlock()
+
+	poolBlocks = append(poolBlocks, block.Hash)
+	if len(poolShares) == 0 || block.Reward == 0 {
+		return
+	}
+
+	total := new(big.Int)
+	byWorker := make(map[string]*big.Int)
+	for _, share := range poolShares {
+		total.Add(total, share.work)
+		if byWorker[share.worker] == nil {
+			byWorker[share.worker] = new(big.Int)
+		}
+		byWorker[share.worker].Add(byWorker[share.worker], share.work)
+	}
+
+	paid := 0
+	reward := big.NewInt(int64(block.Reward))
+	for address, work := range byWorker {
+		amount := int(new(big.Int).Div(new(big.Int).Mul(reward, work), total).Int64())
+		poolWorkers[address].pending += amount
+		paid += amount
+	}
+	poolWorkers[poolShares[len(poolShares)-1].worker].pending += block.Reward - paid
+}
+
+func poolStatus() PoolStatus {
+	poolMutex.Lock()
+	defer poolMutex.Unlock()
+
+	status := PoolStatus{Address: minerAddress, ShareFactor: shareFactor, Window: pplnsWindow, Blocks: append([]string{}, poolBlocks...)}
+
+	since := time.Now().Add(-hashrateWindow)
+	recent := make(map[string]*big.Int)
+	for _, share := range poolShares {
+		if share.time.After(since) {
+			if recent[share.worker] == nil {
+				recent[share.worker] = new(big.Int)
+			}
+			recent[share.worker].Add(recent[share.worker], share.work)
+		}
+	}
+
+	for address, worker := range poolWorkers {
+		hashrate := 0.0
+		if work, ok := recent[address]; ok {
+			hashrate, _ = new(big.Float).Quo(new(big.Float).SetInt(work), big.NewFloat(hashrateWindow.Seconds())).Float64()
+		}
+		status.Workers = append(status.Workers, WorkerStatus{address, worker.shares, hashrate, worker.pending, worker.lastShare})
+	}
+	sort.Slice(status.Workers, func(i, j int) bool { return status.Workers[i].Worker < status.Workers[j].Worker })
+	return status
+}
+
+// pool worker 용 작업 (블록 보상은 pool 주소로, share 목표값 포함)
+func handleGetPoolWork(w http.ResponseWriter, r *http.Request) {
+	work, err := getPoolWork()
+	if err != nil {
+		api.RespondWithJSON(w, r, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	api.RespondWithJSON(w, r, http.StatusOK, work)
+}
+
+// pool worker 의 share 제출
+func handleSubmitShare(w http.ResponseWriter, r *http.Request) {
+	var share Share
+
+	if err := json.NewDecoder(r.Body).Decode(&share); err != nil {
+		api.RespondWithJSON(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	result, err := submitShare(share)
+	if err != nil {
+		api.RespondWithJSON(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if result.Block != nil {
+		api.RespondWithJSON(w, r, http.StatusCreated, result)
+		return
+	}
+	api.RespondWithJSON(w, r, http.StatusAccepted, result)
+}
+
+// worker 별 share, 해쉬레이트, 지급 예정 보상
+func handlePoolStatus(w http.ResponseWriter, r *http.Request) {
+	api.RespondWithJSON(w, r, http.StatusOK, poolStatus())
+}
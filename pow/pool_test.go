@@ -0,0 +1,80 @@
+package pow
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/D0hwQ1/Blockchain-With-Go/chain"
+	"github.com/D0hwQ1/Blockchain-With-Go/wallet"
+)
+
+func TestSubmitShareStaleTemplate(t *testing.T) {
+	pool, err := wallet.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	worker, err := wallet.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesis := chain.DefaultGenesis
+	if powHash, err = Algorithm(genesis.PowHash); err != nil {
+		t.Fatal(err)
+	}
+	genesisConfig, minerAddress = &genesis, pool.Address()
+	Blockchain = chain.New(&genesis, workRule(&genesis))
+
+	tip := Blockchain.Tip()
+	current := chain.NewBlock(tip, chain.Payload{Type: chain.TypeRaw})
+	current.Bits, current.Miner = 0x2000ffff, pool.Address() // share 목표값은 거의 모든 해쉬, 블록 목표값은 1/256
+	stale := current
+	stale.PrevHash = chain.CalculateHash(tip) + "00" // 체인 끝이 바뀐 뒤의 템플릿
+
+	templatesMutex.Lock()
+	templates["current"] = &template{block: current, nextNonce: nonceRange}
+	templates["stale"] = &template{block: stale, nextNonce: nonceRange}
+	templatesMutex.Unlock()
+	defer func() {
+		templatesMutex.Lock()
+		delete(templates, "current")
+		delete(templates, "stale")
+		templatesMutex.Unlock()
+	}()
+
+	var nonce string // share 이지만 블록은 아닌 nonce
+	for n := uint64(0); nonce == ""; n++ {
+		block := current
+		block.Nonce = strconv.FormatUint(n, 16)
+		if hash := powHash(block.Preimage()); chain.MeetsTarget(hash, shareTarget(block.Bits)) && !chain.MeetsTarget(hash, block.Bits.Target()) {
+			nonce = block.Nonce
+		}
+	}
+
+	tests := []struct {
+		id       string
+		accepted bool
+		err      error
+	}{
+		{"stale", false, errStaleWork},
+		{"current", true, nil},
+	}
+	for _, test := range tests {
+		poolMutex.Lock()
+		before := len(poolShares)
+		poolMutex.Unlock()
+
+		result, err := submitShare(Share{test.id, worker.Address(), nonce})
+		if err != test.err {
+			t.Errorf("%s: got %v, want %v", test.id, err, test.err)
+		}
+		if result.Accepted != test.accepted {
+			t.Errorf("%s: got accepted %v, want %v", test.id, result.Accepted, test.accepted)
+		}
+		poolMutex.Lock()
+		recorded := len(poolShares) - before
+		poolMutex.Unlock()
+		if (test.accepted && recorded != 1) || (!test.accepted && recorded != 0) {
+			t.Errorf("%s: %d shares added to the PPLNS window", test.id, recorded)
+		}
+	}
+}
@@ -1,48 +1,60 @@
 package pow
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
+	"context"
 	"encoding/json"
-	"fmt"
-	"io"
+	"errors"
 	"log"
 	"net/http"
-	"strconv"
-	"strings"
+	"os"
 	"sync"
 	"time"
 
+	"github.com/D0hwQ1/Blockchain-With-Go/api"
+	"github.com/D0hwQ1/Blockchain-With-Go/chain"
+	"github.com/D0hwQ1/Blockchain-With-Go/mempool"
+	"github.com/D0hwQ1/Blockchain-With-Go/storage"
+	"github.com/D0hwQ1/Blockchain-With-Go/wallet"
 	"github.com/davecgh/go-spew/spew"
 	"github.com/gorilla/mux"
 )
 
-const difficulty = 3
-
-type Block struct {
-	Index      int    // 데이터 레코드 위치
-	Timestamp  string // 데이터 기록되는 시간
-	BPM        int    // business process management
-	Hash       string // 해당 블록 sha256 해쉬값
-	PrevHash   string // 이전 블록의 sha256 해쉬값
-	Difficulty int    // 해쉬에서 0를 찾을 때, 몇 개를 찾을 지 정하는 변수
-	Nonce      string //
-}
-
-var Blockchain []Block // 체인 선언
+var (
+	errDifficulty = errors.New("block hash is above its target")
+	errMiner      = errors.New("invalid miner address")
+)
 
-type Message struct {
-	BPM int
-}
+var Blockchain *chain.Chain            // 체인 선언
+var txPool *mempool.Mempool            // 블록에 담길 트랜잭션 대기열
+var genesisConfig *chain.GenesisConfig // 목표값 조정, 채굴 보상 설정
+var minerAddress string                // 이 노드가 채굴한 블록의 보상을 받을 주소 (MINER_ADDRESS 환경변수)
+var powHash HashFunc                   // genesis 의 PowHash 알고리즘
 
 var mutex = &sync.Mutex{}
 
 func Start(port string) {
-	t := time.Now()
-	genesisBlock := Block{}
-	genesisBlock = Block{0, t.String(), 0, calculateHash(genesisBlock), "", difficulty, ""} // 첫 블록 생성
-	Blockchain = append(Blockchain, genesisBlock)
-	spew.Dump(genesisBlock)
+	genesis, err := chain.LoadGenesis()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	genesisConfig = genesis
+	if powHash, err = Algorithm(genesis.PowHash); err != nil {
+		log.Fatal(err)
+	}
+	minerAddress = os.Getenv("MINER_ADDRESS")
+	if minerAddress == "" {
+		log.Println("pow: MINER_ADDRESS is not set, mined blocks carry no reward")
+	} else if !wallet.IsAddress(minerAddress) {
+		log.Fatal(errMiner)
+	}
+	Blockchain, err = storage.OpenChain("pow", genesis, workRule(genesis)) // 저장된 체인을 불러오거나 첫 블록 생성
+	if err != nil {
+		log.Fatal(err)
+	}
+	spew.Dump(Blockchain.Blocks())
+	txPool = mempool.New(Blockchain)
+	go runJobs() // POST / 로 들어온 채굴 작업 처리
 
 	log.Fatal(run(port))
 }
@@ -65,115 +77,100 @@ func run(httpPort string) error {
 	return nil
 }
 
-func calculateHash(block Block) string { // 해쉬 생성
-	record := strconv.Itoa(block.Index) + block.Timestamp + strconv.Itoa(block.BPM) + block.PrevHash + strconv.Itoa(block.Difficulty) + block.Nonce
-	h := sha256.New()
-	h.Write([]byte(record))
-	hashed := h.Sum(nil)
-	return hex.EncodeToString(hashed)
-}
+// 페이로드와 대기 중인 트랜잭션으로 블록 생성 - 채굴 중에 다른 블록이 먼저 추가되거나 ctx 가 취소되면 에러
+func generateBlock(ctx context.Context, blocks []chain.Block, payload chain.Payload, txs []chain.Transaction) (chain.Block, error) {
+	newBlock := blockTemplate(blocks, payload, txs, minerAddress)
 
-func isBlockValid(newBlock, oldBlock Block) bool { // 체인 및 블록 변조 체크
-	if oldBlock.Index+1 != newBlock.Index &&
-		oldBlock.Hash != newBlock.PrevHash &&
-		calculateHash(newBlock) != newBlock.Hash {
-		return false
-	} else {
-		return true
-	}
-}
+	ctx, cancel := untilTipChanges(ctx, newBlock.PrevHash)
+	defer cancel()
 
-func isHashValid(hash string, difficulty int) bool {
-	prefix := strings.Repeat("0", difficulty)
-	return strings.HasPrefix(hash, prefix)
+	return mine(ctx, newBlock, powHash)
 }
 
-func generateBlock(oldBlock Block, BPM int) Block { // BPM을 입력받아 블록 생성
-	var newBlock Block
-
-	t := time.Now()
-
-	newBlock.Index = oldBlock.Index + 1
-	newBlock.Timestamp = t.String()
-	newBlock.BPM = BPM
-	newBlock.PrevHash = oldBlock.Hash
-	newBlock.Difficulty = difficulty
-
-	for i := 0; ; i++ {
-		hex := fmt.Sprintf("%x", i)
-		newBlock.Nonce = hex
-		if !isHashValid(calculateHash(newBlock), newBlock.Difficulty) {
-			fmt.Println(calculateHash(newBlock), " do more work!")
-			// time.Sleep(time.Second)
-			continue
-		} else {
-			fmt.Println(calculateHash(newBlock), " work done!")
-			newBlock.Hash = calculateHash(newBlock)
-			break
-		}
+// nonce 를 제외한 새 블록 - 목표값, uncle, 채굴 보상을 채움 (miner 가 비어 있으면 보상 없음)
+func blockTemplate(blocks []chain.Block, payload chain.Payload, txs []chain.Transaction, miner string) chain.Block {
+	oldBlock := blocks[len(blocks)-1]
+	newBlock := chain.NewBlock(oldBlock, payload, txs...)
+	newBlock.Bits = nextBits(genesisConfig, blocks) // RetargetInterval 블록마다 조정된 목표값
+	newBlock.Uncles = pickUncles(blocks)
+	newBlock.UnclesHash = chain.UnclesHash(newBlock.Uncles)
+	newBlock.TotalWork = chain.CumulativeWork(oldBlock, newBlock)
+	if miner != "" {
+		newBlock.Miner, newBlock.Reward = miner, genesisConfig.Reward(newBlock.Index)
 	}
-
 	return newBlock
 }
 
-// fork(분기) 되었을 때, 어느 블록들이 신뢰성을 띄는 지 비교 -> 51% 공격에 무력화 될 수 있음
-func replaceChain(newBlocks []Block) {
-	if len(newBlocks) > len(Blockchain) {
-		Blockchain = newBlocks
-	}
-}
-
 func makeMuxRouter() http.Handler { // 라우터 설정
 	muxRouter := mux.NewRouter()
-	muxRouter.HandleFunc("/", handleGetBlockchain).Methods("get")
+	api.Routes(muxRouter, Blockchain, txPool) // 조회, 트랜잭션, 머클 증명, 감사
 	muxRouter.HandleFunc("/", handleWriteBlock).Methods("POST")
+	muxRouter.HandleFunc("/jobs/{id}", handleGetJob).Methods("GET")
+	muxRouter.HandleFunc("/work", handleGetWork).Methods("GET")
+	muxRouter.HandleFunc("/work", handleSubmitWork).Methods("POST")
+	muxRouter.HandleFunc("/pool", handlePoolStatus).Methods("GET")
+	muxRouter.HandleFunc("/pool/work", handleGetPoolWork).Methods("GET")
+	muxRouter.HandleFunc("/pool/work", handleSubmitShare).Methods("POST")
+	muxRouter.HandleFunc("/balances", handleGetBalances).Methods("GET")
+	muxRouter.HandleFunc("/balances/{address}", handleGetBalance).Methods("GET")
 	return muxRouter
 }
 
-// GET 메소드로 조회되었을 때, 웹뷰에 json으로 가공된 블록 정보 표시
-func handleGetBlockchain(w http.ResponseWriter, r *http.Request) {
-	bytes, err := json.MarshalIndent(Blockchain, "" /* prefix */, "  " /* indent */)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	io.WriteString(w, string(bytes))
-}
-
-// POST 메소드로 네트워크에 요청하면,
+// POST 메소드로 네트워크에 요청하면, 채굴 작업을 대기열에 넣고 작업 ID 를 바로 응답 (채굴은 GET /jobs/{id} 로 확인)
 func handleWriteBlock(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	var msg Message
+	var msg chain.Message
 
 	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
-		respondWithJSON(w, r, http.StatusBadRequest, r.Body)
+		api.RespondWithJSON(w, r, http.StatusBadRequest, r.Body)
 		return
 	}
 	defer r.Body.Close()
 
-	mutex.Lock()
-	prevBlock := Blockchain[len(Blockchain)-1]
-	newBlock := generateBlock(prevBlock, msg.BPM)
+	payload, err := msg.Payload() // {BPM} 또는 {Type, Data}
+	if err != nil {
+		api.RespondWithJSON(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	if isBlockValid(newBlock, prevBlock) {
-		Blockchain = append(Blockchain, newBlock)
-		spew.Dump(Blockchain)
+	job, err := submitJob(payload)
+	if err != nil {
+		api.RespondWithJSON(w, r, http.StatusServiceUnavailable, err.Error())
+		return
 	}
-	mutex.Unlock()
 
-	respondWithJSON(w, r, http.StatusCreated, newBlock)
+	w.Header().Set("Location", "/jobs/"+job.ID)
+	api.RespondWithJSON(w, r, http.StatusAccepted, job)
 }
 
-// 생성한 블록의 정보를 json으로 클라이언트에 response
-func respondWithJSON(w http.ResponseWriter, r *http.Request, code int, payload interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	response, err := json.MarshalIndent(payload, "", "  ")
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("HTTP 500: Internal Server Error"))
+// 채굴 작업 상태 조회 - 끝나면 체인에 추가된 블록을 함께 응답
+func handleGetJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := getJob(mux.Vars(r)["id"])
+	if !ok {
+		api.RespondWithJSON(w, r, http.StatusNotFound, "job not found")
+		return
+	}
+	api.RespondWithJSON(w, r, http.StatusOK, job)
+}
+
+// 주소별 잔액 (genesis 시작 잔액 + 채굴 보상)
+func handleGetBalances(w http.ResponseWriter, r *http.Request) {
+	api.RespondWithJSON(w, r, http.StatusOK, Blockchain.Balances())
+}
+
+func handleGetBalance(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["address"]
+	if !wallet.IsAddress(address) {
+		api.RespondWithJSON(w, r, http.StatusBadRequest, errMiner.Error())
 		return
 	}
+	api.RespondWithJSON(w, r, http.StatusOK, map[string]interface{}{"Address": address, "Balance": Blockchain.Balance(address)})
+}
 
-	w.WriteHeader(code)
-	w.Write(response)
+func Audit() (chain.AuditReport, error) { // 저장된 체인 전체 검증 (CLI audit 명령)
+	genesis, err := chain.LoadGenesis()
+	if err != nil {
+		return chain.AuditReport{}, err
+	}
+	return storage.AuditChain("pow", genesis, workRule(genesis))
 }
@@ -0,0 +1,45 @@
+package pow
+
+import (
+	"sync"
+
+	"github.com/D0hwQ1/Blockchain-With-Go/chain"
+)
+
+// 목표값을 만족했지만 다른 블록이 먼저 체인에 추가되어 들어가지 못한 블록 - 다음 블록들이 uncle 로 담음
+var (
+	staleBlocks []chain.Uncle
+	staleMutex  = &sync.Mutex{}
+)
+
+func addStale(block chain.Block) {
+	staleMutex.Lock()
+	defer staleMutex.Unlock()
+
+	for _, uncle := range staleBlocks {
+		if uncle.Hash == block.Hash {
+			return
+		}
+	}
+	staleBlocks = append(staleBlocks, chain.UncleOf(block))
+}
+
+// blocks 다음 블록에 담을 uncle (최대 chain.MaxUncles 개) - 더 이상 담을 수 없는 후보는 지움
+func pickUncles(blocks []chain.Block) []chain.Uncle {
+	staleMutex.Lock()
+	defer staleMutex.Unlock()
+
+	var uncles []chain.Uncle
+	kept := staleBlocks[:0]
+	for _, uncle := range staleBlocks {
+		if len(blocks)-uncle.Index > chain.MaxUncleDepth {
+			continue
+		}
+		kept = append(kept, uncle)
+		if len(uncles) < chain.MaxUncles && Blockchain.CheckUncle(blocks, uncle) == nil && checkWork(genesisConfig, blocks[:uncle.Index], uncle.Block()) == nil {
+			uncles = append(uncles, uncle)
+		}
+	}
+	staleBlocks = kept
+	return uncles
+}
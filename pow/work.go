@@ -0,0 +1,205 @@
+package pow
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/D0hwQ1/Blockchain-With-Go/api"
+	"github.com/D0hwQ1/Blockchain-With-Go/chain"
+	"github.com/D0hwQ1/Blockchain-With-Go/wallet"
+	"github.com/davecgh/go-spew/spew"
+)
+
+const (
+	nonceRange  = 1 << 32          // getwork 한 번에 나눠 주는 nonce 개수
+	templateAge = 30 * time.Second // 이보다 오래된 템플릿은 mempool 을 다시 담아 새로 만듦
+)
+
+var (
+	errStaleWork = errors.New("work is stale or unknown")
+	errNoRange   = errors.New("template has no nonce range left")
+	errNonce     = errors.New("nonce is not a hex value from an issued range")
+)
+
+// 외부 채굴기에 나눠 주는 작업 - Header 와 Payload 로 만든 chain.Block.Preimage 를 Algorithm 으로 해쉬하며 Nonce 만 바꿔서 대입
+// nonce 는 [NonceStart, NonceEnd) 범위의 정수를 16진수 문자열로 쓴 값 (채굴기마다 범위가 겹치지 않음)
+type Work struct {
+	ID         string
+	Algorithm  string // PoW 해쉬 알고리즘 (pow.Algorithm)
+	Header     chain.Header
+	Payload    chain.Payload
+	Target     string // 256비트 목표값 (64자리 hex), 해쉬가 이 값 이하이면 성공
+	NonceStart uint64
+	NonceEnd   uint64
+}
+
+// 채굴기가 찾은 nonce 제출
+type Solution struct {
+	ID    string
+	Nonce string
+}
+
+type template struct {
+	block     chain.Block // Miner 는 getwork 를 요청한 채굴기의 주소
+	created   time.Time
+	nextNonce uint64
+}
+
+var (
+	templates      = make(map[string]*template)
+	templatesMutex = &sync.Mutex{}
+)
+
+// 현재 체인 끝에 이을 템플릿에서 다음 nonce 범위를 나눠 줌 - 보상은 miner 주소로
+func getWork(miner string) (Work, error) {
+	templatesMutex.Lock()
+	defer templatesMutex.Unlock()
+
+	tip := Blockchain.Tip()
+	id, tmpl := currentTemplate(tip.Hash, miner)
+	if tmpl == nil {
+		var err error
+		if id, tmpl, err = newTemplate(miner); err != nil {
+			return Work{}, err
+		}
+	}
+	if tmpl.nextNonce > ^uint64(0)-nonceRange {
+		return Work{}, errNoRange
+	}
+
+	work := Work{
+		ID:         id,
+		Algorithm:  genesisConfig.PowHash,
+		Header:     tmpl.block.Header,
+		Payload:    tmpl.block.Payload,
+		Target:     hex.EncodeToString(tmpl.block.Bits.Target().FillBytes(make([]byte, 32))),
+		NonceStart: tmpl.nextNonce,
+		NonceEnd:   tmpl.nextNonce + nonceRange,
+	}
+	tmpl.nextNonce += nonceRange
+	return work, nil
+}
+
+// tip 위에 miner 에게 보상하도록 만든, 오래되지 않은 템플릿 (templatesMutex 를 잡은 상태에서 호출)
+func currentTemplate(tipHash, miner string) (string, *template) {
+	for id, tmpl := range templates {
+		if tmpl.block.PrevHash != tipHash { // 체인 끝이 바뀌어 더 이상 이을 수 없는 템플릿
+			delete(templates, id)
+			continue
+		}
+		if tmpl.block.Miner == miner && time.Since(tmpl.created) < templateAge {
+			return id, tmpl
+		}
+	}
+	return "", nil
+}
+
+// mempool 의 트랜잭션을 담은 새 템플릿 - 페이로드는 비어 있는 바이트 (templatesMutex 를 잡은 상태에서 호출)
+func newTemplate(miner string) (string, *template, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+
+	block := blockTemplate(Blockchain.Blocks(), chain.Payload{Type: chain.TypeRaw}, txPool.Batch(chain.MaxTransactions), miner)
+
+	id := hex.EncodeToString(b)
+	tmpl := &template{block: block, created: time.Now()}
+	templates[id] = tmpl
+	return id, tmpl, nil
+}
+
+// 제출된 nonce 로 블록을 완성해 체인에 추가
+func submitWork(solution Solution) (chain.Block, error) {
+	newBlock, hash, err := solve(solution)
+	if err != nil {
+		return newBlock, err
+	}
+	if !chain.MeetsTarget(hash, newBlock.Bits.Target()) {
+		return newBlock, errDifficulty
+	}
+	return newBlock, appendSolved(newBlock)
+}
+
+// 템플릿에 nonce 를 넣어 블록을 완성하고 PoW 해쉬를 계산
+// nonce 는 그 템플릿에서 이미 나눠 준 범위 ([0, nextNonce)) 의 값을 정규 16진수 (소문자, 앞의 0 없음) 로 쓴 것이어야 함
+func solve(solution Solution) (chain.Block, []byte, error) {
+	nonce, err := strconv.ParseUint(solution.Nonce, 16, 64)
+	if err != nil || strconv.FormatUint(nonce, 16) != solution.Nonce { // 같은 nonce 를 다른 표기로 다시 해쉬하지 못하도록
+		return chain.Block{}, nil, errNonce
+	}
+
+	templatesMutex.Lock()
+	tmpl, ok := templates[solution.ID]
+	issued := ok && nonce < tmpl.nextNonce
+	templatesMutex.Unlock()
+	if !ok {
+		return chain.Block{}, nil, errStaleWork
+	}
+	if !issued {
+		return chain.Block{}, nil, errNonce
+	}
+
+	newBlock := tmpl.block
+	newBlock.Nonce = solution.Nonce
+	newBlock.Hash = chain.CalculateHash(newBlock)
+	return newBlock, powHash(newBlock.Preimage()), nil
+}
+
+func appendSolved(newBlock chain.Block) error { // 목표값을 만족한 블록을 체인에 추가
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if Blockchain.Tip().Hash != newBlock.PrevHash { // 늦게 찾은 블록은 다음 블록에 uncle 로 담음
+		addStale(newBlock)
+		return errStaleWork
+	}
+	if err := Blockchain.Append(newBlock); err != nil {
+		return err
+	}
+	txPool.Remove(newBlock.Transactions)
+	spew.Dump(Blockchain.Blocks())
+	return nil
+}
+
+// 외부 채굴기용 작업 템플릿 (getwork) - ?miner=주소 로 보상 받을 주소 지정 (없으면 노드의 MINER_ADDRESS)
+func handleGetWork(w http.ResponseWriter, r *http.Request) {
+	miner := r.URL.Query().Get("miner")
+	if miner == "" {
+		miner = minerAddress
+	} else if !wallet.IsAddress(miner) {
+		api.RespondWithJSON(w, r, http.StatusBadRequest, errMiner.Error())
+		return
+	}
+
+	work, err := getWork(miner)
+	if err != nil {
+		api.RespondWithJSON(w, r, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	api.RespondWithJSON(w, r, http.StatusOK, work)
+}
+
+// 외부 채굴기가 찾은 nonce 제출 (submitwork) - 성공하면 체인에 추가된 블록을 응답
+func handleSubmitWork(w http.ResponseWriter, r *http.Request) {
+	var solution Solution
+
+	if err := json.NewDecoder(r.Body).Decode(&solution); err != nil {
+		api.RespondWithJSON(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	newBlock, err := submitWork(solution)
+	if err != nil {
+		api.RespondWithJSON(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	api.RespondWithJSON(w, r, http.StatusCreated, newBlock)
+}
@@ -0,0 +1,47 @@
+package pow
+
+import (
+	"testing"
+
+	"github.com/D0hwQ1/Blockchain-With-Go/chain"
+)
+
+func TestSolveNonce(t *testing.T) {
+	var err error
+	if powHash, err = Algorithm("sha256"); err != nil {
+		t.Fatal(err)
+	}
+	block := chain.NewBlock(chain.DefaultGenesis.Block(), chain.Payload{Type: chain.TypeRaw})
+	templatesMutex.Lock()
+	templates["issued"] = &template{block: block, nextNonce: 2 * nonceRange} // 범위 두 개를 나눠 줌
+	templatesMutex.Unlock()
+	defer func() {
+		templatesMutex.Lock()
+		delete(templates, "issued")
+		templatesMutex.Unlock()
+	}()
+
+	tests := []struct {
+		id    string
+		nonce string
+		err   error
+	}{
+		{"issued", "0", nil},
+		{"issued", "1ffffffff", nil}, // 두 번째 범위의 마지막
+		{"issued", "200000000", errNonce},
+		{"issued", "00ff", errNonce}, // 앞의 0
+		{"issued", "FF", errNonce},   // 대문자
+		{"issued", "", errNonce},
+		{"issued", "xyz", errNonce},
+		{"unknown", "0", errStaleWork},
+	}
+	for _, test := range tests {
+		newBlock, _, err := solve(Solution{test.id, test.nonce})
+		if err != test.err {
+			t.Errorf("%s %q: got %v, want %v", test.id, test.nonce, err, test.err)
+		}
+		if err == nil && newBlock.Nonce != test.nonce {
+			t.Errorf("%s %q: block has nonce %q", test.id, test.nonce, newBlock.Nonce)
+		}
+	}
+}
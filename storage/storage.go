@@ -0,0 +1,423 @@
+package storage
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/D0hwQ1/Blockchain-With-Go/chain"
+)
+
+const (
+	maxFileSize = 16 << 20 // 블록 파일 하나의 최대 크기, 넘으면 다음 파일로 넘어감
+	recordHead  = 8        // 레코드 헤더: 길이(4) + crc32(4)
+	entrySize   = 16       // 인덱스 엔트리: 파일 번호(4) + 오프셋(8) + 길이(4)
+	indexName   = "index.dat"
+)
+
+var ErrCorrupt = errors.New("storage record is corrupt")
+
+type position struct {
+	file   uint32 // 블록이 저장된 파일 번호
+	offset uint64 // 파일 내 레코드 시작 위치
+	length uint32 // 레코드(헤더 포함) 길이
+}
+
+// 데이터 디렉토리에 블록 파일(blk00000.dat, ...)과 인덱스(index.dat)로 블록을 append-only 저장
+type Store struct {
+	mutex sync.Mutex
+	dir   string
+	index []position // 높이(Index)별 블록 위치
+	file  *os.File   // 현재 이어 쓰는 블록 파일
+	num   uint32     // 현재 블록 파일 번호
+	size  int64      // 현재 블록 파일 크기
+	idx   *os.File
+}
+
+func Dir(name string) string { // 모드별 데이터 디렉토리 (DATA_DIR 환경변수, 기본값 data)
+	dir := os.Getenv("DATA_DIR")
+	if dir == "" {
+		dir = "data"
+	}
+	return filepath.Join(dir, name)
+}
+
+// 데이터 디렉토리를 열고, 비정상 종료로 잘리거나 깨진 마지막 레코드를 복구
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	s := &Store{dir: dir}
+	rewrite, err := s.recover()
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := os.OpenFile(filepath.Join(dir, indexName), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	s.idx = idx
+	if rewrite {
+		if err := s.writeIndex(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.openFile(s.num); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) fileName(num uint32) string {
+	return blockFile(s.dir, num)
+}
+
+func blockFile(dir string, num uint32) string {
+	return filepath.Join(dir, fmt.Sprintf("blk%05d.dat", num))
+}
+
+// index.dat 에 저장된 위치 - 위치가 앞 레코드에 이어지지 않거나 마지막 레코드가 블록 파일과 맞지 않으면 ok 가 false
+// end 는 마지막 레코드가 끝나는 위치 (여기부터 인덱스에 쓰기 전에 종료되어 빠진 레코드를 찾음)
+func loadIndex(dir string) (index []position, end position, ok bool) {
+	data, err := os.ReadFile(filepath.Join(dir, indexName))
+	if os.IsNotExist(err) {
+		return nil, end, true
+	}
+	if err != nil || len(data)%entrySize != 0 {
+		return nil, end, false
+	}
+
+	for buf := data; len(buf) > 0; buf = buf[entrySize:] {
+		p := position{
+			file:   binary.BigEndian.Uint32(buf[0:4]),
+			offset: binary.BigEndian.Uint64(buf[4:12]),
+			length: binary.BigEndian.Uint32(buf[12:16]),
+		}
+		next := p == position{end.file, end.offset, p.length}             // 같은 파일에서 앞 레코드 바로 뒤
+		if len(index) > 0 && p == (position{end.file + 1, 0, p.length}) { // 다음 파일의 처음
+			next = true
+		}
+		if !next || p.length < recordHead {
+			return nil, position{}, false
+		}
+		index = append(index, p)
+		end = position{file: p.file, offset: p.offset + uint64(p.length)}
+	}
+
+	if len(index) > 0 { // 인덱스보다 블록 파일이 먼저 잘렸으면 마지막 레코드가 맞지 않음
+		last := index[len(index)-1]
+		f, err := os.Open(blockFile(dir, last.file))
+		if err != nil {
+			return nil, position{}, false
+		}
+		defer f.Close()
+		if length, err := readRecord(f, int64(last.offset), nil); err != nil || length != last.length {
+			return nil, position{}, false
+		}
+	}
+	return index, end, true
+}
+
+// end 위치부터 블록 파일을 순서대로 읽어 레코드 위치를 index 에 이어 붙임 (파일을 바꾸지 않음)
+// end 는 마지막 블록 파일의 번호와 읽은 끝 위치, 깨진 레코드를 만나면 그 위치에서 멈추고 ErrCorrupt 를 감싼 에러를 돌려줌
+func scan(dir string, index []position, end position) ([]position, position, error) {
+	for num := end.file; ; num++ {
+		f, err := os.Open(blockFile(dir, num))
+		if os.IsNotExist(err) {
+			return index, end, nil
+		}
+		if err != nil {
+			return nil, end, err
+		}
+
+		if num != end.file {
+			end = position{file: num}
+		}
+		for {
+			length, err := readRecord(f, int64(end.offset), nil)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				f.Close()
+				return index, end, fmt.Errorf("%s at offset %d: %w", blockFile(dir, num), end.offset, err)
+			}
+			index = append(index, position{num, end.offset, length})
+			end.offset += uint64(length)
+		}
+		f.Close()
+	}
+}
+
+// index.dat 의 위치를 불러와 그 뒤의 레코드만 다시 읽고 (index.dat 가 맞지 않으면 처음부터), 깨진 첫 레코드부터 뒤는 잘라냄
+// index.dat 를 다시 써야 하면 true
+func (s *Store) recover() (bool, error) {
+	index, end, ok := loadIndex(s.dir)
+	if !ok {
+		log.Printf("storage: %s does not match the block files, scanning them all", filepath.Join(s.dir, indexName))
+	}
+	indexed := len(index)
+
+	index, end, err := scan(s.dir, index, end)
+	if err != nil && !errors.Is(err, ErrCorrupt) {
+		return false, err
+	}
+	s.index, s.num, s.size = index, end.file, int64(end.offset)
+	if err == nil {
+		return !ok || len(index) != indexed, nil
+	}
+
+	log.Printf("storage: truncating %v", err)
+	if err := os.Truncate(s.fileName(end.file), int64(end.offset)); err != nil {
+		return false, err
+	}
+	return true, s.removeFrom(end.file + 1)
+}
+
+func (s *Store) removeFrom(num uint32) error { // num 번 이후의 블록 파일 삭제
+	for ; ; num++ {
+		err := os.Remove(s.fileName(num))
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Store) openFile(num uint32) error {
+	if s.file != nil {
+		s.file.Close()
+	}
+	f, err := os.OpenFile(s.fileName(num), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.file, s.num, s.size = f, num, info.Size()
+	return nil
+}
+
+func (s *Store) writeIndex() error { // 메모리의 인덱스로 index.dat 를 다시 씀
+	buf := make([]byte, 0, len(s.index)*entrySize)
+	for _, p := range s.index {
+		buf = appendEntry(buf, p)
+	}
+	if err := s.idx.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := s.idx.WriteAt(buf, 0); err != nil {
+		return err
+	}
+	return s.idx.Sync()
+}
+
+func appendEntry(buf []byte, p position) []byte {
+	var entry [entrySize]byte
+	binary.BigEndian.PutUint32(entry[0:4], p.file)
+	binary.BigEndian.PutUint64(entry[4:12], p.offset)
+	binary.BigEndian.PutUint32(entry[12:16], p.length)
+	return append(buf, entry[:]...)
+}
+
+// offset 위치의 레코드를 읽어 검증 (out 이 nil 이 아니면 본문을 디코딩)
+func readRecord(f *os.File, offset int64, out *chain.Block) (uint32, error) {
+	var head [recordHead]byte
+	n, err := f.ReadAt(head[:], offset)
+	if n == 0 && err == io.EOF {
+		return 0, io.EOF
+	}
+	if n < recordHead {
+		return 0, ErrCorrupt
+	}
+
+	length := binary.BigEndian.Uint32(head[0:4])
+	sum := binary.BigEndian.Uint32(head[4:8])
+	if length > maxFileSize {
+		return 0, ErrCorrupt
+	}
+
+	body := make([]byte, length)
+	if _, err := f.ReadAt(body, offset+recordHead); err != nil {
+		return 0, ErrCorrupt
+	}
+	if crc32.ChecksumIEEE(body) != sum {
+		return 0, ErrCorrupt
+	}
+
+	if out != nil {
+		if err := out.UnmarshalBinary(body); err != nil {
+			return 0, err
+		}
+	}
+	return recordHead + length, nil
+}
+
+func (s *Store) Load() ([]chain.Block, error) { // 저장된 모든 블록을 높이 순서대로 읽음
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	blocks := make([]chain.Block, 0, len(s.index))
+	for i := range s.index {
+		block, err := s.read(i)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+func (s *Store) Block(height int) (chain.Block, error) { // 인덱스로 해당 높이의 블록만 읽음
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if height < 0 || height >= len(s.index) {
+		return chain.Block{}, fmt.Errorf("storage: no block at height %d", height)
+	}
+	return s.read(height)
+}
+
+func (s *Store) read(height int) (chain.Block, error) {
+	return readBlock(s.dir, s.index[height])
+}
+
+func readBlock(dir string, p position) (chain.Block, error) {
+	var block chain.Block
+
+	f, err := os.Open(blockFile(dir, p.file))
+	if err != nil {
+		return block, err
+	}
+	defer f.Close()
+
+	_, err = readRecord(f, int64(p.offset), &block)
+	return block, err
+}
+
+// 블록 파일 끝에 레코드를 쓰고 fsync 한 뒤 인덱스 엔트리를 추가
+func (s *Store) Append(block chain.Block) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	body, err := block.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	record := make([]byte, recordHead+len(body))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(body)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(body))
+	copy(record[recordHead:], body)
+
+	if s.size > 0 && s.size+int64(len(record)) > maxFileSize {
+		if err := s.openFile(s.num + 1); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.file.WriteAt(record, s.size); err != nil {
+		return err
+	}
+	if err := s.file.Sync(); err != nil {
+		return err
+	}
+
+	p := position{s.num, uint64(s.size), uint32(len(record))}
+	if _, err := s.idx.WriteAt(appendEntry(nil, p), int64(len(s.index))*entrySize); err != nil {
+		return err
+	}
+	if err := s.idx.Sync(); err != nil {
+		return err
+	}
+
+	s.index = append(s.index, p)
+	s.size += int64(len(record))
+	return nil
+}
+
+// 앞의 n 개 블록만 남기고 잘라냄 (fork 로 체인이 교체될 때 사용)
+func (s *Store) Truncate(n int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if n >= len(s.index) {
+		return nil
+	}
+
+	p := s.index[n]
+	if err := s.openFile(p.file); err != nil {
+		return err
+	}
+	if err := s.removeFrom(p.file + 1); err != nil {
+		return err
+	}
+	if err := s.file.Truncate(int64(p.offset)); err != nil {
+		return err
+	}
+	s.size = int64(p.offset)
+
+	s.index = s.index[:n]
+	if err := s.idx.Truncate(int64(n) * entrySize); err != nil {
+		return err
+	}
+	return s.idx.Sync()
+}
+
+func (s *Store) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	return s.idx.Close()
+}
+
+// 모드 이름의 데이터 디렉토리에서 체인을 불러옴 (각 모드의 Start 에서 사용)
+func OpenChain(name string, genesis *chain.GenesisConfig, rules ...chain.Rule) (*chain.Chain, error) {
+	store, err := Open(Dir(name))
+	if err != nil {
+		return nil, err
+	}
+	return chain.Open(store, genesis, rules...)
+}
+
+// 모드 이름의 데이터 디렉토리에 저장된 체인 전체를 검증 (CLI audit 명령)
+// 파일을 읽기만 하므로 실행 중인 노드의 데이터도 검증할 수 있고, 깨진 꼬리 레코드는 복구하지 않고 실패로 보고함
+func AuditChain(name string, genesis *chain.GenesisConfig, rules ...chain.Rule) (chain.AuditReport, error) {
+	dir := Dir(name)
+	index, _, scanErr := scan(dir, nil, position{})
+	if scanErr != nil && !errors.Is(scanErr, ErrCorrupt) {
+		return chain.AuditReport{}, scanErr
+	}
+
+	blocks := make([]chain.Block, 0, len(index))
+	for _, p := range index {
+		block, err := readBlock(dir, p)
+		if err != nil {
+			return chain.AuditReport{}, err
+		}
+		blocks = append(blocks, block)
+	}
+
+	report := chain.AuditBlocks(genesis, blocks, rules...)
+	if scanErr != nil && report.Valid { // 읽은 블록은 유효하지만 그 뒤의 레코드가 깨짐
+		report.Valid = false
+		report.Height, report.Rule, report.Error = len(blocks), "storage", scanErr.Error()
+	}
+	return report, nil
+}
@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/D0hwQ1/Blockchain-With-Go/chain"
+)
+
+func testBlocks(n int) []chain.Block {
+	blocks := []chain.Block{chain.DefaultGenesis.Block()}
+	for i := 1; i < n; i++ {
+		blocks = append(blocks, chain.GenerateBlock(blocks[i-1], chain.BPMPayload(60+i)))
+	}
+	return blocks
+}
+
+func writeBlocks(t *testing.T, dir string, blocks []chain.Block) {
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, block := range blocks {
+		if err := s.Append(block); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func appendBytes(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func truncateBy(path string, n int64) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.Truncate(path, info.Size()-n)
+}
+
+func TestRecover(t *testing.T) {
+	blocks := testBlocks(4)
+	blk := func(dir string) string { return blockFile(dir, 0) }
+	idx := func(dir string) string { return filepath.Join(dir, indexName) }
+
+	tests := []struct {
+		name   string
+		damage func(dir string) error
+		want   int // 복구 후 남는 블록 수
+	}{
+		{"clean", func(dir string) error { return nil }, 4},
+		{"torn body", func(dir string) error { return truncateBy(blk(dir), 3) }, 3},
+		{"torn head", func(dir string) error { return appendBytes(blk(dir), []byte{0, 0, 0}) }, 4},
+		{"bad checksum", func(dir string) error {
+			data, err := os.ReadFile(blk(dir))
+			if err != nil {
+				return err
+			}
+			data[len(data)-1] ^= 0xff
+			return os.WriteFile(blk(dir), data, 0644)
+		}, 3},
+		{"index ahead of blocks", func(dir string) error { // 블록 파일만 잘린 경우
+			index, _, ok := loadIndex(dir)
+			if !ok {
+				return ErrCorrupt
+			}
+			return os.Truncate(blk(dir), int64(index[2].offset)+5)
+		}, 2},
+		{"index behind blocks", func(dir string) error { // 인덱스에 쓰기 전에 종료된 경우
+			return os.Truncate(idx(dir), 2*entrySize)
+		}, 4},
+		{"torn index entry", func(dir string) error { return os.Truncate(idx(dir), 3*entrySize+7) }, 4},
+		{"no index", func(dir string) error { return os.Remove(idx(dir)) }, 4},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeBlocks(t, dir, blocks)
+			if err := test.damage(dir); err != nil {
+				t.Fatal(err)
+			}
+
+			s, err := Open(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			loaded, err := s.Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(loaded) != test.want {
+				t.Fatalf("got %d blocks, want %d", len(loaded), test.want)
+			}
+			for i, block := range loaded {
+				if block.Hash != blocks[i].Hash {
+					t.Fatalf("block %d: got hash %s, want %s", i, block.Hash, blocks[i].Hash)
+				}
+			}
+
+			// 복구한 끝에 이어 쓰고 다시 열어도 인덱스와 블록 파일이 맞아야 함
+			for _, block := range blocks[test.want:] {
+				if err := s.Append(block); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := s.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if _, _, ok := loadIndex(dir); !ok {
+				t.Fatal("index.dat does not match the block files after recovery")
+			}
+			s, err = Open(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+			if loaded, err := s.Load(); err != nil || len(loaded) != len(blocks) {
+				t.Fatalf("after append: got %d blocks, %v", len(loaded), err)
+			}
+			info, err := os.Stat(idx(dir))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Size() != int64(len(blocks))*entrySize {
+				t.Errorf("index.dat has %d bytes, want %d", info.Size(), len(blocks)*entrySize)
+			}
+		})
+	}
+}
+
+func TestAuditChainReadOnly(t *testing.T) {
+	t.Setenv("DATA_DIR", t.TempDir())
+	dir := Dir("test")
+	writeBlocks(t, dir, testBlocks(3))
+	if err := appendBytes(blockFile(dir, 0), []byte{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.ReadFile(blockFile(dir, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := AuditChain("test", &chain.DefaultGenesis)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Valid || report.Rule != "storage" || report.Height != 3 {
+		t.Errorf("got %+v, want a storage failure at height 3", report)
+	}
+	after, err := os.ReadFile(blockFile(dir, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(before) {
+		t.Error("audit changed the block file")
+	}
+}
@@ -2,41 +2,40 @@ package tcp
 
 import (
 	"bufio"
-	"crypto/sha256"
-	"encoding/hex"
-	"fmt"
 	"io"
 	"log"
 	"net"
-	"strconv"
+	"strings"
 	"sync"
-	"time"
 
+	"github.com/D0hwQ1/Blockchain-With-Go/api"
+	"github.com/D0hwQ1/Blockchain-With-Go/chain"
+	"github.com/D0hwQ1/Blockchain-With-Go/mempool"
+	"github.com/D0hwQ1/Blockchain-With-Go/storage"
 	"github.com/davecgh/go-spew/spew"
 )
 
-type Block struct {
-	Index     int    // 데이터 레코드 위치
-	Timestamp string // 데이터 기록되는 시간
-	BPM       int    // business process management
-	Hash      string // 해당 블록 sha256 해쉬값
-	PrevHash  string // 이전 블록의 sha256 해쉬값
-}
-
-var bcServer chan []Block
-var Blockchain []Block // 체인 선언
+var bcServer chan []chain.Block
+var Blockchain *chain.Chain // 체인 선언
+var txPool *mempool.Mempool // 블록에 담길 트랜잭션 대기열
 
 var mutex = &sync.Mutex{}
 
 func Start(port string) {
 
-	bcServer = make(chan []Block)
+	bcServer = make(chan []chain.Block)
+
+	genesis, err := chain.LoadGenesis()
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	t := time.Now()
-	genesisBlock := Block{}
-	genesisBlock = Block{0, t.String(), 0, calculateHash(genesisBlock), ""} // 첫 블록 생성
-	Blockchain = append(Blockchain, genesisBlock)
-	spew.Dump(genesisBlock)
+	Blockchain, err = storage.OpenChain("tcp", genesis) // 저장된 체인을 불러오거나 첫 블록 생성
+	if err != nil {
+		log.Fatal(err)
+	}
+	spew.Dump(Blockchain.Blocks())
+	txPool = mempool.New(Blockchain)
 
 	server, err := net.Listen("tcp", ":"+port) // tcp 통신 서버 오픈
 	if err != nil {
@@ -56,75 +55,45 @@ func Start(port string) {
 func handleConn(conn net.Conn) { // tcp에 통신한 클라이언트의 블록 생성
 	defer conn.Close()
 
-	io.WriteString(conn, "Enter a new BPM:")
+	io.WriteString(conn, "Enter a new BPM (or JSON/text payload):")
 	scanner := bufio.NewScanner(conn)
 
 	go func() {
 		for scanner.Scan() {
-			bpm, err := strconv.Atoi(scanner.Text())
-			if err != nil {
-				io.WriteString(conn, fmt.Sprintf("%v not a number: %s\nEnter a new BPM:", scanner.Text(), err))
+			if line := scanner.Text(); strings.HasPrefix(line, "tx ") { // "tx {서명된 트랜잭션 JSON}" 은 mempool 로
+				io.WriteString(conn, api.AddTransaction(txPool, strings.TrimPrefix(line, "tx ")))
+				io.WriteString(conn, "\nEnter a new BPM (or JSON/text payload):")
 				continue
 			}
-			newBlock, err := generateBlock(Blockchain[len(Blockchain)-1], bpm)
-			if err != nil {
+
+			payload := chain.ParsePayload(scanner.Text()) // 정수면 BPM, JSON 이면 JSON, 나머지는 바이트
+
+			mutex.Lock()
+			newBlock := chain.GenerateBlock(Blockchain.Tip(), payload, txPool.Batch(chain.MaxTransactions)...)
+			if err := Blockchain.Append(newBlock); err != nil {
 				log.Println(err)
-				continue
-			}
-			if isBlockValid(newBlock, Blockchain[len(Blockchain)-1]) {
-				newBlockchain := append(Blockchain, newBlock)
-				replaceChain(newBlockchain)
+			} else {
+				txPool.Remove(newBlock.Transactions)
 			}
+			mutex.Unlock()
 
-			spew.Dump(Blockchain)
-			bcServer <- Blockchain
+			spew.Dump(Blockchain.Blocks())
+			bcServer <- Blockchain.Blocks()
 			spew.Dump(bcServer)
 
-			io.WriteString(conn, "\nEnter a new BPM:")
+			io.WriteString(conn, "\nEnter a new BPM (or JSON/text payload):")
 		}
 	}()
 
 	for _ = range bcServer {
-		spew.Dump(Blockchain)
-	}
-}
-
-func calculateHash(block Block) string { // 해쉬 생성
-	record := strconv.Itoa(block.Index) + block.Timestamp + strconv.Itoa(block.BPM) + block.PrevHash
-	h := sha256.New()
-	h.Write([]byte(record))
-	hashed := h.Sum(nil)
-	return hex.EncodeToString(hashed)
-}
-
-func isBlockValid(newBlock, oldBlock Block) bool { // 체인 및 블록 변조 체크
-	if oldBlock.Index+1 != newBlock.Index &&
-		oldBlock.Hash != newBlock.PrevHash &&
-		calculateHash(newBlock) != newBlock.Hash {
-		return false
-	} else {
-		return true
+		spew.Dump(Blockchain.Blocks())
 	}
 }
 
-func generateBlock(oldBlock Block, BPM int) (Block, error) { // BPM을 입력받아 블록 생성
-	var newBlock Block
-
-	t := time.Now()
-
-	newBlock.Index = oldBlock.Index + 1
-	newBlock.Timestamp = t.String()
-	newBlock.BPM = BPM
-	newBlock.PrevHash = oldBlock.Hash
-	newBlock.Hash = calculateHash(newBlock)
-
-	return newBlock, nil
-}
-
-// fork(분기) 되었을 때, 어느 블록들이 신뢰성을 띄는 지 비교 -> 51% 공격에 무력화 될 수 있음
-func replaceChain(newBlocks []Block) {
-	fmt.Println(len(newBlocks), len(Blockchain))
-	if len(newBlocks) > len(Blockchain) {
-		Blockchain = newBlocks
+func Audit() (chain.AuditReport, error) { // 저장된 체인 전체 검증 (CLI audit 명령)
+	genesis, err := chain.LoadGenesis()
+	if err != nil {
+		return chain.AuditReport{}, err
 	}
+	return storage.AuditChain("tcp", genesis)
 }
@@ -0,0 +1,134 @@
+package wallet
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+var (
+	ErrPublicKey = errors.New("wallet: invalid public key")
+	ErrSignature = errors.New("wallet: invalid signature")
+	ErrAddress   = errors.New("wallet: address does not match public key")
+)
+
+// ed25519 키 쌍 - 주소는 공개키로부터 만들어지므로 서명으로 주소의 주인임을 증명할 수 있음
+type Wallet struct {
+	priv ed25519.PrivateKey
+}
+
+// 지갑 파일에 저장되는 형태
+type keyFile struct {
+	Address   string
+	PublicKey string
+	Seed      string // 개인키 seed (hex)
+}
+
+func Generate() (*Wallet, error) { // 새 키 쌍 생성
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &Wallet{priv: priv}, nil
+}
+
+func FromSeed(seed []byte) (*Wallet, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, errors.New("wallet: invalid seed")
+	}
+	return &Wallet{priv: ed25519.NewKeyFromSeed(seed)}, nil
+}
+
+func Load(path string) (*Wallet, error) { // 지갑 파일 읽기
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var key keyFile
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, err
+	}
+	seed, err := hex.DecodeString(key.Seed)
+	if err != nil {
+		return nil, err
+	}
+	return FromSeed(seed)
+}
+
+func (w *Wallet) Save(path string) error { // 지갑 파일 저장 (소유자만 읽을 수 있게)
+	data, err := json.MarshalIndent(keyFile{w.Address(), w.PublicKey(), hex.EncodeToString(w.priv.Seed())}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+func Dir() string { // 지갑 파일 디렉토리 (WALLET_DIR 환경변수, 기본값 wallets)
+	if dir := os.Getenv("WALLET_DIR"); dir != "" {
+		return dir
+	}
+	return "wallets"
+}
+
+func (w *Wallet) PublicKey() string { // 공개키 (hex)
+	return hex.EncodeToString(w.priv.Public().(ed25519.PublicKey))
+}
+
+func (w *Wallet) Address() string {
+	address, _ := AddressOf(w.PublicKey())
+	return address
+}
+
+func (w *Wallet) Sign(msg []byte) string { // msg 에 대한 서명 (hex)
+	return hex.EncodeToString(ed25519.Sign(w.priv, msg))
+}
+
+// 공개키로부터 주소 생성: 0x + sha256(공개키) 의 마지막 20바이트
+func AddressOf(pubKey string) (string, error) {
+	pub, err := hex.DecodeString(pubKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return "", ErrPublicKey
+	}
+	h := sha256.Sum256(pub)
+	return "0x" + hex.EncodeToString(h[len(h)-20:]), nil
+}
+
+func IsAddress(address string) bool { // AddressOf 가 만드는 형태 (0x + 40자리 hex) 인지
+	if len(address) != 42 || address[:2] != "0x" {
+		return false
+	}
+	_, err := hex.DecodeString(address[2:])
+	return err == nil
+}
+
+func Verify(pubKey string, msg []byte, signature string) error { // pubKey 의 주인이 msg 에 서명했는지 확인
+	pub, err := hex.DecodeString(pubKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return ErrPublicKey
+	}
+	sig, err := hex.DecodeString(signature)
+	if err != nil || !ed25519.Verify(pub, msg, sig) {
+		return ErrSignature
+	}
+	return nil
+}
+
+// address 가 pubKey 로부터 만들어진 주소이고, 그 키로 msg 에 서명했는지 확인
+func VerifyAddress(address, pubKey string, msg []byte, signature string) error {
+	derived, err := AddressOf(pubKey)
+	if err != nil {
+		return err
+	}
+	if derived != address {
+		return ErrAddress
+	}
+	return Verify(pubKey, msg, signature)
+}